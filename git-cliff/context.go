@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// A release in the changelog context.
+type Release struct {
+	// Release version, empty for unreleased changes.
+	Version string
+	// Release message, e.g. the tag message.
+	Message string
+	// Commit ID (SHA) of the release.
+	CommitID string
+	// Release time as a unix timestamp.
+	Timestamp int
+	// Version of the previous release, empty if this is the first release.
+	PreviousVersion string
+	// Commits included in the release.
+	Commits []*Commit
+	// Contributors of the release's pull requests, only set if a remote is configured.
+	Contributors []*Contributor
+}
+
+// A commit in the changelog context.
+type Commit struct {
+	// Commit ID (SHA).
+	ID string
+	// Commit message. For conventional commits this is the description without type and scope.
+	Message string
+	// Full, unparsed commit message.
+	RawMessage string
+	// Commit body, if any.
+	Body string
+	// Group assigned by the matching commit parser.
+	Group string
+	// Conventional commit scope, or the scope assigned by the matching commit parser.
+	Scope string
+	// Whether the commit is a conventional commit.
+	Conventional bool
+	// Whether the commit introduces a breaking change.
+	Breaking bool
+	// Description of the breaking change, if any.
+	BreakingDescription string
+	// Whether the commit is a merge commit.
+	MergeCommit bool
+	// Conventional commit footers.
+	Footers []*Footer
+	// Links extracted with the configured link parsers.
+	Links []*Link
+	// Author of the commit.
+	Author *Signature
+	// Committer of the commit.
+	Committer *Signature
+	// Pull request the commit was merged in, only set if a remote is configured.
+	Remote *Contributor
+}
+
+// A conventional commit footer, e.g. "Signed-off-by: name".
+type Footer struct {
+	// Footer token, e.g. "Signed-off-by".
+	Token string
+	// Separator between token and value, e.g. ":".
+	Separator string
+	// Footer value.
+	Value string
+	// Whether the footer is a breaking change footer.
+	Breaking bool
+}
+
+// A link extracted from a commit message.
+type Link struct {
+	// Text of the link.
+	Text string
+	// URL of the link.
+	Href string
+}
+
+// A pull request or merge request and its author, fetched from the remote.
+type Contributor struct {
+	// Username of the pull request author.
+	Username string
+	// Pull request number, 0 if the commit was not merged through a pull request.
+	PullRequest int
+	// Pull request title.
+	PullRequestTitle string
+	// Pull request labels.
+	Labels []string
+	// Whether this is the author's first contribution to the repository.
+	FirstTime bool
+}
+
+// Author or committer of a commit.
+type Signature struct {
+	Name  string
+	Email string
+	// Signature time as a unix timestamp.
+	Timestamp int
+}
+
+// Changelog context of the selected releases, parsed from `git-cliff --context`.
+// Unreleased changes are versioned with the bumped tag if tag is not provided.
+// +cache="never"
+func (gc *GitCliff) Context(ctx context.Context,
+	//tag to use for unreleased changes
+	// +optional
+	tag string,
+	//commits to generate the context for, defaults to all commits
+	// +optional
+	commits *Range,
+) ([]*Release, error) {
+	if commits == nil {
+		commits = gc.All()
+	}
+
+	cmd := gc.Command
+	cmd = append(cmd, "--context")
+	cmd = append(cmd, gc.rangeArgs(commits, tag)...)
+
+	out, err := gc.Container.WithExec(cmd).Stdout(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate changelog context: %w", err)
+	}
+
+	return parseContext(out)
+}
+
+// cliffRelease is a release as serialized by `git-cliff --context`.
+type cliffRelease struct {
+	Version   string        `json:"version"`
+	Message   string        `json:"message"`
+	CommitID  string        `json:"commit_id"`
+	Timestamp int           `json:"timestamp"`
+	Commits   []cliffCommit `json:"commits"`
+	Previous  *struct {
+		Version string `json:"version"`
+	} `json:"previous"`
+	Github cliffRemoteRelease `json:"github"`
+	Gitlab cliffRemoteRelease `json:"gitlab"`
+	Gitea  cliffRemoteRelease `json:"gitea"`
+}
+
+// cliffCommit is a commit as serialized by `git-cliff --context`.
+type cliffCommit struct {
+	ID                  string `json:"id"`
+	Message             string `json:"message"`
+	RawMessage          string `json:"raw_message"`
+	Body                string `json:"body"`
+	Group               string `json:"group"`
+	Scope               string `json:"scope"`
+	Conventional        bool   `json:"conventional"`
+	Breaking            bool   `json:"breaking"`
+	BreakingDescription string `json:"breaking_description"`
+	MergeCommit         bool   `json:"merge_commit"`
+	Footers             []struct {
+		Token     string `json:"token"`
+		Separator string `json:"separator"`
+		Value     string `json:"value"`
+		Breaking  bool   `json:"breaking"`
+	} `json:"footers"`
+	Links []struct {
+		Text string `json:"text"`
+		Href string `json:"href"`
+	} `json:"links"`
+	Author    cliffSignature    `json:"author"`
+	Committer cliffSignature    `json:"committer"`
+	Remote    *cliffContributor `json:"remote"`
+}
+
+// cliffRemoteRelease is the remote metadata of a release, only populated for the configured remote.
+type cliffRemoteRelease struct {
+	Contributors []cliffContributor `json:"contributors"`
+}
+
+type cliffContributor struct {
+	Username    *string  `json:"username"`
+	PRTitle     *string  `json:"pr_title"`
+	PRNumber    *int     `json:"pr_number"`
+	PRLabels    []string `json:"pr_labels"`
+	IsFirstTime bool     `json:"is_first_time"`
+}
+
+type cliffSignature struct {
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Timestamp int    `json:"timestamp"`
+}
+
+// parseContext converts the JSON output of `git-cliff --context` into releases.
+func parseContext(data string) ([]*Release, error) {
+	var raw []cliffRelease
+	if err := json.Unmarshal([]byte(data), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse changelog context: %w", err)
+	}
+
+	releases := make([]*Release, 0, len(raw))
+	for _, r := range raw {
+		release := &Release{
+			Version:   r.Version,
+			Message:   r.Message,
+			CommitID:  r.CommitID,
+			Timestamp: r.Timestamp,
+			Commits:   make([]*Commit, 0, len(r.Commits)),
+		}
+		if r.Previous != nil {
+			release.PreviousVersion = r.Previous.Version
+		}
+		for _, remote := range []cliffRemoteRelease{r.Github, r.Gitlab, r.Gitea} {
+			for _, c := range remote.Contributors {
+				release.Contributors = append(release.Contributors, c.contributor())
+			}
+		}
+
+		for _, c := range r.Commits {
+			commit := &Commit{
+				ID:                  c.ID,
+				Message:             c.Message,
+				RawMessage:          c.RawMessage,
+				Body:                c.Body,
+				Group:               c.Group,
+				Scope:               c.Scope,
+				Conventional:        c.Conventional,
+				Breaking:            c.Breaking,
+				BreakingDescription: c.BreakingDescription,
+				MergeCommit:         c.MergeCommit,
+				Author:              c.Author.signature(),
+				Committer:           c.Committer.signature(),
+			}
+			if c.Remote != nil {
+				commit.Remote = c.Remote.contributor()
+			}
+			for _, f := range c.Footers {
+				commit.Footers = append(commit.Footers, &Footer{
+					Token:     f.Token,
+					Separator: f.Separator,
+					Value:     f.Value,
+					Breaking:  f.Breaking,
+				})
+			}
+			for _, l := range c.Links {
+				commit.Links = append(commit.Links, &Link{
+					Text: l.Text,
+					Href: l.Href,
+				})
+			}
+			release.Commits = append(release.Commits, commit)
+		}
+
+		releases = append(releases, release)
+	}
+
+	return releases, nil
+}
+
+func (s cliffSignature) signature() *Signature {
+	return &Signature{
+		Name:      s.Name,
+		Email:     s.Email,
+		Timestamp: s.Timestamp,
+	}
+}
+
+func (c cliffContributor) contributor() *Contributor {
+	contributor := &Contributor{
+		Labels:    c.PRLabels,
+		FirstTime: c.IsFirstTime,
+	}
+	if c.Username != nil {
+		contributor.Username = *c.Username
+	}
+	if c.PRNumber != nil {
+		contributor.PullRequest = *c.PRNumber
+	}
+	if c.PRTitle != nil {
+		contributor.PullRequestTitle = *c.PRTitle
+	}
+	return contributor
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"dagger/git-cliff/internal/dagger"
+	"dagger/git-cliff/util"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Built-in git-cliff configuration template.
+// See: https://git-cliff.org/docs/templating/examples
+type Preset string
+
+const (
+	// Changelog following https://keepachangelog.com.
+	PresetKeepachangelog Preset = "KEEPACHANGELOG"
+	// Changelog resembling GitHub's generated release notes.
+	PresetGithub Preset = "GITHUB"
+	// Changelog with commit links and breaking change details.
+	PresetDetailed Preset = "DETAILED"
+	// Changelog with commits grouped by scope.
+	PresetScoped Preset = "SCOPED"
+)
+
+// Create a cliff.toml in the working directory from a preset.
+//
+// The include paths, exclude paths and tag pattern provided to GitCliff are added to the configuration,
+// scoping it to a monorepo component.
+// +cache="never"
+func (gc *GitCliff) Init(ctx context.Context,
+	// configuration template to start from
+	preset Preset,
+	// use emoji group headers, e.g. "🐛 Bug Fixes"
+	// +optional
+	emoji bool,
+	// overwrite an existing cliff.toml
+	// +optional
+	force bool,
+) (*dagger.Changeset, error) {
+	src := gc.Gitref.Tree()
+	configPath := path.Join(gc.WorkingDir, "cliff.toml")
+
+	exists, err := src.Exists(ctx, configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if %s exists: %w", configPath, err)
+	}
+	if exists && !force {
+		return nil, fmt.Errorf("%s already exists", configPath)
+	}
+
+	// generate outside of the source, an existing cliff.toml would be overwritten
+	const initDir = "/work/init"
+	config, err := gc.Container.
+		WithWorkdir(initDir).
+		WithExec([]string{"git-cliff", "--init", strings.ToLower(string(preset))}).
+		File(path.Join(initDir, "cliff.toml")).
+		Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s preset: %w", preset, err)
+	}
+
+	if emoji {
+		config = util.EmojiGroups(config)
+	}
+	if len(gc.IncludePaths) != 0 {
+		config = util.SetOption(config, "git", "include_paths", util.TOMLStringArray(gc.IncludePaths))
+	}
+	if len(gc.ExcludePaths) != 0 {
+		config = util.SetOption(config, "git", "exclude_paths", util.TOMLStringArray(gc.ExcludePaths))
+	}
+	if gc.TagPattern != "" {
+		config = util.SetOption(config, "git", "tag_pattern", util.TOMLString(gc.TagPattern))
+	}
+
+	return src.WithNewFile(configPath, config).Changes(src), nil
+}
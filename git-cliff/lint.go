@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Check that every commit in a range is a conventional commit matching a commit parser in cliff.toml.
+// Returns a report of the checked commits, and fails listing the SHA and subject of every commit that does not match,
+// grouped by reason.
+// Commits skipped by a commit parser are considered a match.
+// +cache="never"
+func (gc *GitCliff) LintCommits(ctx context.Context,
+	// start of the commit range (exclusive), e.g. a tag or the merge request's target branch
+	from string,
+	// end of the commit range (inclusive)
+	// +optional
+	// +default="HEAD"
+	to string,
+	// accept commits that are not conventional commits if they match a commit parser
+	// +optional
+	allowUnconventional bool,
+) (string, error) {
+	cmd := gc.Command
+	cmd = append(cmd, "--context", fmt.Sprintf("%s..%s", from, to))
+
+	// keep every commit in the context so the ones that do not match can be reported
+	out, err := gc.Container.
+		WithEnvVariable("GIT_CLIFF__GIT__FILTER_UNCONVENTIONAL", "false").
+		WithEnvVariable("GIT_CLIFF__GIT__REQUIRE_CONVENTIONAL", "false").
+		WithEnvVariable("GIT_CLIFF__GIT__FILTER_COMMITS", "false").
+		WithExec(cmd).
+		Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate changelog context: %w", err)
+	}
+
+	releases, err := parseContext(out)
+	if err != nil {
+		return "", err
+	}
+
+	const (
+		unconventional = "not conventional commits"
+		unmatched      = "not matching a commit parser"
+	)
+	var total, failed int
+	invalid := map[string][]string{}
+	for _, release := range releases {
+		for _, commit := range release.Commits {
+			total++
+			var reason string
+			switch {
+			case !commit.Conventional && !allowUnconventional:
+				reason = unconventional
+			case commit.Group == "":
+				reason = unmatched
+			default:
+				continue
+			}
+			failed++
+			invalid[reason] = append(invalid[reason], fmt.Sprintf("  %.7s %s", commit.ID, commit.subject()))
+		}
+	}
+
+	if failed != 0 {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%d of %d commits in %s..%s are invalid", failed, total, from, to)
+		for _, reason := range []string{unconventional, unmatched} {
+			if commits := invalid[reason]; len(commits) != 0 {
+				fmt.Fprintf(&sb, "\n%s:\n%s", reason, strings.Join(commits, "\n"))
+			}
+		}
+		return "", errors.New(sb.String())
+	}
+
+	return fmt.Sprintf("all %d commits in %s..%s match a commit parser\n", total, from, to), nil
+}
+
+// subject is the first line of the full commit message.
+func (c *Commit) subject() string {
+	msg := c.RawMessage
+	if msg == "" {
+		msg = c.Message
+	}
+	subject, _, _ := strings.Cut(msg, "\n")
+	return strings.TrimSpace(subject)
+}
@@ -2,13 +2,17 @@
 // This module uses a git reference as a source directory to scan for changes
 // and create a changelog or bump a version. A cliff.toml can be used for additional
 // customization if found in the working directory where GitCliff is being ran.
+// In a monorepo, commits and tags can be scoped to a single component with
+// include/exclude paths and a tag pattern.
 package main
 
 import (
 	"context"
 	"dagger/git-cliff/internal/dagger"
+	"dagger/git-cliff/util"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 )
 
@@ -24,6 +28,16 @@ type GitCliff struct {
 	Gitref *dagger.GitRef
 	// +private
 	Command []string
+	// +private
+	WorkingDir string
+	// +private
+	IncludePaths []string
+	// +private
+	ExcludePaths []string
+	// +private
+	TagPattern string
+	// +private
+	Remote RemoteKind
 }
 
 // +cache="never"
@@ -48,12 +62,30 @@ func New(ctx context.Context,
 	// See: https://git-cliff.org/docs/integration/gitea
 	// +optional
 	giteaToken *dagger.Secret,
+	// only include commits that changed these paths (glob patterns relative to the repository root), e.g. "my-module/**"
+	// +optional
+	includePaths []string,
+	// exclude commits that only changed these paths (glob patterns relative to the repository root)
+	// +optional
+	excludePaths []string,
+	// regex of tags to consider as releases, e.g. "my-module/v[0-9]+\.[0-9]+\.[0-9]+$"
+	// +optional
+	tagPattern string,
 ) *GitCliff {
 
 	//convert git-ref to a *dagger.Directory
 	gitRefDir := gitRef.Tree(dagger.GitRefTreeOpts{Depth: -1})
 	//default git-cliff cmd
 	cmd := []string{"git-cliff", "--use-native-tls"}
+	for _, p := range includePaths {
+		cmd = append(cmd, "--include-path", p)
+	}
+	for _, p := range excludePaths {
+		cmd = append(cmd, "--exclude-path", p)
+	}
+	if tagPattern != "" {
+		cmd = append(cmd, "--tag-pattern", tagPattern)
+	}
 	srcDir := "/work/src"
 	//base git-cliff container
 	ctr := dag.Container().
@@ -83,14 +115,21 @@ func New(ctx context.Context,
 	}
 
 	return &GitCliff{
-		Container: ctr,
-		Command:   cmd,
-		Gitref:    gitRef,
+		Container:    ctr,
+		Command:      slices.Clip(cmd),
+		Gitref:       gitRef,
+		WorkingDir:   workingDir,
+		IncludePaths: includePaths,
+		ExcludePaths: excludePaths,
+		TagPattern:   tagPattern,
 	}
 }
 
 // generate a changelog file with unreleased changes and bumps the tag if tag is not provided
-// If file already exists, it will prepend to the existing changelog instead of creating a new one.
+// If file already exists, it will prepend to the existing changelog instead of creating a new one,
+// unless all commits are selected, which regenerates the full changelog.
+// The header, body and footer templates of cliff.toml can be overridden with git-cliff templates.
+// See: https://git-cliff.org/docs/templating/context
 // +cache="never"
 func (gc *GitCliff) Changelog(
 	ctx context.Context,
@@ -101,36 +140,47 @@ func (gc *GitCliff) Changelog(
 	//tag to generate changelog for
 	// +optional
 	tag string,
-) *dagger.File {
+	//commits to generate the changelog for, defaults to unreleased commits
+	// +optional
+	commits *Range,
+	//template rendered at the start of the changelog, instead of the header in cliff.toml
+	// +optional
+	header string,
+	//template rendered for each release, instead of the body in cliff.toml
+	// +optional
+	body string,
+	//template rendered at the end of the changelog, instead of the footer in cliff.toml.
+	//The footer is stripped if not provided.
+	// +optional
+	footer string,
+) (*dagger.File, error) {
+	if commits == nil {
+		commits = gc.Unreleased()
+	}
+
 	cmd := gc.Command
-	cmd = append(cmd,
-		"--unreleased",
-		"--strip",
-		"footer",
-	)
+	cmd = append(cmd, gc.rangeArgs(commits, tag)...)
+	ctr, cmd := withTemplates(gc.Container, cmd, header, body, footer, false)
 
-	//use provided tag, otherwise bump automatically
-	if tag != "" {
-		cmd = append(cmd, "--tag", tag)
-	} else {
-		cmd = append(cmd, "--bump")
-	}
 	//check if changelog exists and either prepend or generate new changelog file
-	exists, err := gc.Container.Exists(ctx, changelog)
+	exists, err := ctr.Exists(ctx, changelog)
 	if err != nil {
-		panic(fmt.Errorf("failed to check if %s exists: %w", changelog, err))
+		return nil, fmt.Errorf("failed to check if %s exists: %w", changelog, err)
 	}
 
-	if exists {
+	if exists && commits.Mode != RangeModeAll {
 		cmd = append(cmd, "--prepend", changelog)
 	} else {
 		cmd = append(cmd, "--output", changelog)
 	}
 
-	return gc.Container.WithExec(cmd).File(changelog)
+	return ctr.WithExec(cmd).File(changelog), nil
 }
 
 // generate release notes file with unreleased changes and bumps the tag if tag is not provided
+// The header, body and footer templates of cliff.toml can be overridden with git-cliff templates,
+// which can render extra notes with {{ extra_notes }}.
+// See: https://git-cliff.org/docs/templating/context
 // +cache="never"
 func (gc *GitCliff) ReleaseNotes(
 	ctx context.Context,
@@ -141,47 +191,208 @@ func (gc *GitCliff) ReleaseNotes(
 	//tag to generate changelog for
 	// +optional
 	tag string,
-	// append additional provided release notes
+	// additional release notes, inserted after the release heading unless a template renders {{ extra_notes }}
 	// +optional
 	extraNotes string,
-) *dagger.File {
-	cmd := gc.Command
-	cmd = append(cmd,
-		"--unreleased",
-		"--strip",
-		"all",
-	)
+	//commits to generate release notes for, defaults to unreleased commits
+	// +optional
+	commits *Range,
+	//template rendered before the release notes. The header is stripped if not provided.
+	// +optional
+	header string,
+	//template rendered for the release, instead of the body in cliff.toml
+	// +optional
+	body string,
+	//template rendered after the release notes. The footer is stripped if not provided.
+	// +optional
+	footer string,
+	//append the pull requests and first-time contributors, fetched from the remote set with WithRemote
+	// +optional
+	pullRequests bool,
+) (*dagger.File, error) {
+	if commits == nil {
+		commits = gc.Unreleased()
+	}
 
-	//use provided tag, otherwise bump automatically
-	if tag != "" {
-		cmd = append(cmd, "--tag", tag)
-	} else {
-		cmd = append(cmd, "--bump")
+	rendersNotes := false
+	for _, tmpl := range []*string{&header, &body, &footer} {
+		if !util.UsesTemplateVar(*tmpl, "extra_notes") {
+			continue
+		}
+		rendersNotes = true
+		var err error
+		if *tmpl, err = util.WithTemplateVar(*tmpl, "extra_notes", extraNotes); err != nil {
+			return nil, err
+		}
 	}
 
-	//generate release notes and append any extraNotes provided
-	releaseNotes, err := gc.Container.WithExec(cmd).Stdout(ctx)
+	cmd := gc.Command
+	cmd = append(cmd, gc.rangeArgs(commits, tag)...)
+	ctr, cmd := withTemplates(gc.Container, cmd, header, body, footer, true)
+
+	releaseNotes, err := ctr.WithExec(cmd).Stdout(ctx)
 	if err != nil {
-		panic(fmt.Errorf("failed to generate release notes: %w", err))
+		return nil, fmt.Errorf("failed to generate release notes: %w", err)
+	}
+
+	//templates render the extra notes themselves
+	if extraNotes != "" && !rendersNotes {
+		releaseNotes = util.InsertNotes(releaseNotes, extraNotes)
+	}
+
+	if pullRequests {
+		remoteNotes, err := gc.remoteNotes(ctx, commits, tag)
+		if err != nil {
+			return nil, err
+		}
+		if remoteNotes != "" {
+			releaseNotes = strings.TrimRight(releaseNotes, "\n") + "\n\n" + remoteNotes
+		}
+	}
+
+	return gc.Container.WithNewFile(notes, releaseNotes).File(notes), nil
+}
+
+// withTemplates overrides the templates of cliff.toml that are provided, and strips the footer,
+// and the header if stripHeader is set, unless they are overridden.
+func withTemplates(ctr *dagger.Container, cmd []string, header, body, footer string, stripHeader bool) (*dagger.Container, []string) {
+	if header != "" {
+		ctr = ctr.WithEnvVariable("GIT_CLIFF__CHANGELOG__HEADER", header)
+		stripHeader = false
+	}
+	if body != "" {
+		cmd = append(cmd, "--body", body)
+	}
+	if footer != "" {
+		ctr = ctr.WithEnvVariable("GIT_CLIFF__CHANGELOG__FOOTER", footer)
 	}
 
-	if extraNotes != "" {
-		releaseNotes = strings.Replace(releaseNotes, "###", extraNotes+"\n###", 1)
+	switch {
+	case stripHeader && footer == "":
+		cmd = append(cmd, "--strip", "all")
+	case stripHeader:
+		cmd = append(cmd, "--strip", "header")
+	case footer == "":
+		cmd = append(cmd, "--strip", "footer")
+	}
+
+	return ctr, cmd
+}
+
+// rangeArgs returns the git-cliff arguments selecting a range of commits.
+// Unreleased commits are tagged with the provided tag, otherwise the tag is bumped automatically.
+func (gc *GitCliff) rangeArgs(commits *Range, tag string) []string {
+	args := commits.args()
+
+	//use provided tag, otherwise bump automatically
+	switch {
+	case tag != "":
+		args = append(args, "--tag", tag)
+	case commits.includesUnreleased():
+		args = append(args, "--bump")
 	}
 
-	return gc.Container.WithExec(cmd).WithNewFile(notes, releaseNotes).File(notes)
+	return args
 }
 
+// Semantic version component to bump.
+type BumpLevel string
+
+const (
+	// Infer the component to bump from the unreleased commits.
+	BumpLevelAuto BumpLevel = "AUTO"
+	// Bump the major version.
+	BumpLevelMajor BumpLevel = "MAJOR"
+	// Bump the minor version.
+	BumpLevelMinor BumpLevel = "MINOR"
+	// Bump the patch version.
+	BumpLevelPatch BumpLevel = "PATCH"
+)
+
+// prereleaseTags matches tags with a semver prerelease, e.g. v1.4.0-rc.1.
+const prereleaseTags = `[0-9]+\.[0-9]+\.[0-9]+-`
+
 // Prints a bumped tag for unreleased changes.
+//
+// With a prerelease identifier, the bump is calculated from the latest release, ignoring existing
+// prerelease tags, and the next prerelease of that version is returned, e.g. v1.4.0-rc.2 if
+// v1.4.0-rc.1 is already tagged.
 // +cache="never"
 func (gc *GitCliff) BumpedVersion(ctx context.Context,
+	// version component to bump, inferred from commits by default
+	// +optional
+	// +default="AUTO"
+	bump BumpLevel,
+	// prerelease identifier, e.g. "rc"
+	// +optional
+	prerelease string,
 ) (string, error) {
 	cmd := gc.Command
 	cmd = append(cmd,
 		"--bumped-version",
 	)
+	if bump != "" && bump != BumpLevelAuto {
+		cmd = append(cmd, "--bump", strings.ToLower(string(bump)))
+	}
+
+	if prerelease == "" {
+		return bumpedVersion(ctx, gc.Container.WithExec(cmd))
+	}
+
+	config, err := gc.config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// prereleases do not count as a release, so the version is bumped from the latest release
+	ignoreTags := prereleaseTags
+	if configured, ok := util.Option(config, "git", "ignore_tags"); ok && configured != "" {
+		ignoreTags = configured + "|" + prereleaseTags
+	}
+	version, err := bumpedVersion(ctx, gc.Container.
+		WithEnvVariable("GIT_CLIFF__GIT__IGNORE_TAGS", ignoreTags).
+		WithExec(cmd))
+	if err != nil || version == "" {
+		return version, err
+	}
+
+	tags, err := gc.Gitref.Tree(dagger.GitRefTreeOpts{Depth: -1}).AsGit().Tags(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error listing tags: %w", err)
+	}
+
+	// only count prereleases of the tags git-cliff considers, e.g. of the same monorepo component
+	tagPattern := gc.TagPattern
+	if tagPattern == "" {
+		tagPattern, _ = util.Option(config, "git", "tag_pattern")
+	}
+	tags, err = util.MatchingTags(tags, tagPattern)
+	if err != nil {
+		return "", err
+	}
+
+	return util.NextPrerelease(version, prerelease, tags)
+}
+
+// config returns the contents of cliff.toml in the working directory, or an empty string if there is none.
+func (gc *GitCliff) config(ctx context.Context) (string, error) {
+	exists, err := gc.Container.Exists(ctx, "cliff.toml")
+	if err != nil {
+		return "", fmt.Errorf("failed to check if cliff.toml exists: %w", err)
+	}
+	if !exists {
+		return "", nil
+	}
+
+	config, err := gc.Container.File("cliff.toml").Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cliff.toml: %w", err)
+	}
+	return config, nil
+}
 
-	ctr := gc.Container.WithExec(cmd)
+// bumpedVersion returns the output of `git-cliff --bumped-version`, or an empty string if there is nothing to bump.
+func bumpedVersion(ctx context.Context, ctr *dagger.Container) (string, error) {
 	// The check below is needed due to how git-cliff returns its warning/error logs.
 	//  Warnings are returned as errors and not stdout
 	stderr, err := ctr.Stderr(ctx)
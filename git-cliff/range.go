@@ -0,0 +1,111 @@
+package main
+
+import "fmt"
+
+// Mode of selecting the commits in a Range.
+type RangeMode string
+
+const (
+	// Commits that are not part of a release yet.
+	//
+	// e.g. `git-cliff --unreleased`.
+	RangeModeUnreleased RangeMode = "UNRELEASED"
+
+	// Commits of the latest release.
+	//
+	// e.g. `git-cliff --latest`.
+	RangeModeLatest RangeMode = "LATEST"
+
+	// Commits of the release tagged at the current commit.
+	//
+	// e.g. `git-cliff --current`.
+	RangeModeCurrent RangeMode = "CURRENT"
+
+	// All commits, regenerating every release from scratch.
+	//
+	// e.g. `git-cliff`.
+	RangeModeAll RangeMode = "ALL"
+
+	// Commits between two revisions.
+	//
+	// e.g. `git-cliff v1.2.0..v1.3.0`.
+	RangeModeBetween RangeMode = "BETWEEN"
+)
+
+// Commits to generate a changelog, release notes or context for.
+type Range struct {
+	// Mode of selecting commits.
+	Mode RangeMode
+	// Start of the range (exclusive), only set for BETWEEN.
+	From string
+	// End of the range (inclusive), only set for BETWEEN.
+	To string
+}
+
+// Commits that are not part of a release yet.
+//
+// e.g. `git-cliff --unreleased`.
+func (gc *GitCliff) Unreleased() *Range {
+	return &Range{Mode: RangeModeUnreleased}
+}
+
+// Commits of the latest release.
+//
+// e.g. `git-cliff --latest`.
+func (gc *GitCliff) Latest() *Range {
+	return &Range{Mode: RangeModeLatest}
+}
+
+// Commits of the release tagged at the current commit.
+//
+// e.g. `git-cliff --current`.
+func (gc *GitCliff) Current() *Range {
+	return &Range{Mode: RangeModeCurrent}
+}
+
+// All commits, regenerating every release from scratch.
+//
+// e.g. `git-cliff`.
+func (gc *GitCliff) All() *Range {
+	return &Range{Mode: RangeModeAll}
+}
+
+// Commits between two revisions, e.g. two tags.
+//
+// e.g. `git-cliff <from>..<to>`.
+func (gc *GitCliff) Between(
+	// start of the range (exclusive), e.g. v1.2.0
+	from string,
+	// end of the range (inclusive), e.g. v1.3.0
+	// +optional
+	// +default="HEAD"
+	to string,
+) *Range {
+	return &Range{Mode: RangeModeBetween, From: from, To: to}
+}
+
+// args returns the git-cliff arguments selecting the range's commits.
+func (r *Range) args() []string {
+	switch r.Mode {
+	case RangeModeUnreleased:
+		return []string{"--unreleased"}
+	case RangeModeLatest:
+		return []string{"--latest"}
+	case RangeModeCurrent:
+		return []string{"--current"}
+	case RangeModeBetween:
+		to := r.To
+		if to == "" {
+			to = "HEAD"
+		}
+		return []string{fmt.Sprintf("%s..%s", r.From, to)}
+	default:
+		return nil
+	}
+}
+
+// includesUnreleased reports whether the range contains unreleased commits,
+// which need a tag or a bumped version.
+func (r *Range) includesUnreleased() bool {
+	return r.Mode == RangeModeUnreleased || r.Mode == RangeModeAll
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"dagger/git-cliff/internal/dagger"
+	"dagger/git-cliff/util"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Git hosting service to fetch pull request and contributor metadata from.
+// Private repositories need the matching token provided to GitCliff.
+type RemoteKind string
+
+const (
+	// GitHub, see https://git-cliff.org/docs/integration/github
+	RemoteKindGithub RemoteKind = "GITHUB"
+	// GitLab, see https://git-cliff.org/docs/integration/gitlab
+	RemoteKindGitlab RemoteKind = "GITLAB"
+	// Gitea, see https://git-cliff.org/docs/integration/gitea
+	RemoteKindGitea RemoteKind = "GITEA"
+)
+
+// Fetch pull request and contributor metadata from a remote, overriding the remote in cliff.toml.
+func (gc *GitCliff) WithRemote(
+	// hosting service of the repository
+	kind RemoteKind,
+	// repository on the remote, e.g. "owner/repo"
+	repo string,
+	// API URL of a self-hosted instance, e.g. "https://gitea.example.com" or "https://gitlab.example.com/api/v4"
+	// +optional
+	apiUrl string,
+	// service serving the API URL, e.g. a Gitea instance running in Dagger
+	// +optional
+	service *dagger.Service,
+) (*GitCliff, error) {
+	if _, _, ok := strings.Cut(repo, "/"); !ok {
+		return nil, fmt.Errorf("remote repository %q must be in the form owner/repo", repo)
+	}
+
+	kindName := strings.ToLower(string(kind))
+	gc.Command = slices.Clip(append(gc.Command, fmt.Sprintf("--%s-repo", kindName), repo))
+	gc.Remote = kind
+
+	if apiUrl != "" {
+		gc.Container = gc.Container.WithEnvVariable(fmt.Sprintf("%s_API_URL", kind), apiUrl)
+	}
+
+	if service != nil {
+		u, err := url.Parse(apiUrl)
+		if err != nil || u.Hostname() == "" {
+			return nil, fmt.Errorf("an API URL with a host is required to bind the remote service, got %q", apiUrl)
+		}
+		gc.Container = gc.Container.WithServiceBinding(u.Hostname(), service)
+	}
+
+	return gc, nil
+}
+
+// remoteNotes renders the pull requests and first-time contributors of the releases in a range.
+func (gc *GitCliff) remoteNotes(ctx context.Context, commits *Range, tag string) (string, error) {
+	if gc.Remote == "" {
+		return "", fmt.Errorf("a remote is required to fetch pull requests, see WithRemote")
+	}
+
+	// git-cliff only fetches remote metadata used in the body template
+	kindName := strings.ToLower(string(gc.Remote))
+	body := fmt.Sprintf("{%% for commit in commits %%}{{ commit.remote.pr_number }}{%% endfor %%}{{ %s.contributors }}", kindName)
+
+	cmd := gc.Command
+	cmd = append(cmd, "--context", "--body", body)
+	cmd = append(cmd, gc.rangeArgs(commits, tag)...)
+
+	out, err := gc.Container.WithExec(cmd).Stdout(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch remote metadata: %w", err)
+	}
+
+	releases, err := parseContext(out)
+	if err != nil {
+		return "", err
+	}
+
+	// commits carry their pull request, contributors whether it is the author's first contribution
+	var pullRequests []util.PullRequest
+	firstTime := map[string]bool{}
+	for _, release := range releases {
+		for _, commit := range release.Commits {
+			if commit.Remote == nil {
+				continue
+			}
+			pullRequests = append(pullRequests, util.PullRequest{
+				Number:    commit.Remote.PullRequest,
+				Title:     commit.Remote.PullRequestTitle,
+				Author:    commit.Remote.Username,
+				FirstTime: commit.Remote.FirstTime,
+			})
+		}
+		for _, contributor := range release.Contributors {
+			if contributor.FirstTime {
+				firstTime[contributor.Username] = true
+			}
+		}
+	}
+	for i, pr := range pullRequests {
+		pullRequests[i].FirstTime = pr.FirstTime || firstTime[pr.Author]
+	}
+
+	prefix := "#"
+	if gc.Remote == RemoteKindGitlab {
+		prefix = "!"
+	}
+
+	return util.RemoteNotes(pullRequests, prefix), nil
+}
@@ -0,0 +1,170 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// emojiGroups maps commit parser group names to group headers with an emoji,
+// prefixed with an html comment to order the groups in the changelog.
+var emojiGroups = map[string]string{
+	"features":            "<!-- 0 -->🚀 Features",
+	"added":               "<!-- 0 -->🚀 Added",
+	"bug fixes":           "<!-- 1 -->🐛 Bug Fixes",
+	"fixed":               "<!-- 1 -->🐛 Fixed",
+	"refactor":            "<!-- 2 -->🚜 Refactor",
+	"changed":             "<!-- 2 -->🚜 Changed",
+	"documentation":       "<!-- 3 -->📚 Documentation",
+	"performance":         "<!-- 4 -->⚡ Performance",
+	"styling":             "<!-- 5 -->🎨 Styling",
+	"testing":             "<!-- 6 -->🧪 Testing",
+	"miscellaneous tasks": "<!-- 7 -->⚙️ Miscellaneous Tasks",
+	"security":            "<!-- 8 -->🛡️ Security",
+	"revert":              "<!-- 9 -->◀️ Revert",
+	"deprecated":          "<!-- 9 -->⚠️ Deprecated",
+	"removed":             "<!-- 9 -->🗑️ Removed",
+	"other":               "<!-- 10 -->💼 Other",
+}
+
+var (
+	// groupRegex matches the group of a commit parser, capturing its name without an existing order comment or emoji.
+	groupRegex = regexp.MustCompile(`group\s*=\s*"(?:<!--\s*\d+\s*-->)?[^\p{L}"]*([^"]*?)\s*"`)
+	// groupFilterRegex matches a group rendered in a template with a filter other than striptags first.
+	groupFilterRegex = regexp.MustCompile(`\{\{\s*group\s*\|\s*(upper_first|trim|title|upper|lower)\b`)
+	// groupPlainRegex matches a group rendered in a template without filters.
+	groupPlainRegex = regexp.MustCompile(`\{\{\s*group\s*\}\}`)
+	// tableRegex matches a TOML table header, e.g. [git].
+	tableRegex = regexp.MustCompile(`^\s*\[[^\[\]]+\]\s*(#.*)?$`)
+)
+
+// EmojiGroups replaces the groups of well known commit parsers in a cliff.toml with emoji group headers,
+// e.g. "Bug Fixes" becomes "<!-- 1 -->🐛 Bug Fixes", and strips the ordering comment when rendering group headers.
+func EmojiGroups(config string) string {
+	config = groupRegex.ReplaceAllStringFunc(config, func(match string) string {
+		name := groupRegex.FindStringSubmatch(match)[1]
+		group, ok := emojiGroups[strings.ToLower(name)]
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf("group = %s", TOMLString(group))
+	})
+
+	config = groupFilterRegex.ReplaceAllString(config, "{{ group | striptags | trim | $1")
+	return groupPlainRegex.ReplaceAllString(config, "{{ group | striptags | trim }}")
+}
+
+// SetOption sets a key to a TOML encoded value in a table of a cliff.toml, e.g. tag_pattern in [git].
+// An existing, or commented out, key in the table is replaced. Otherwise the key is added to the top of the table,
+// adding the table if it does not exist.
+func SetOption(config, table, key, value string) string {
+	keyRegex := regexp.MustCompile(`^\s*#?\s*` + regexp.QuoteMeta(key) + `\s*=`)
+	option := fmt.Sprintf("%s = %s", key, value)
+
+	lines := strings.Split(config, "\n")
+	header := -1
+	for i, line := range lines {
+		if tableRegex.MatchString(line) {
+			if header >= 0 {
+				// reached the next table
+				break
+			}
+			if strings.TrimSpace(strings.Split(line, "#")[0]) == "["+table+"]" {
+				header = i
+			}
+			continue
+		}
+		if header >= 0 && keyRegex.MatchString(line) {
+			lines[i] = option
+			return strings.Join(lines, "\n")
+		}
+	}
+
+	if header < 0 {
+		return strings.TrimRight(config, "\n") + fmt.Sprintf("\n\n[%s]\n%s\n", table, option)
+	}
+
+	lines = append(lines[:header+1], append([]string{option}, lines[header+1:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+// Option returns the string value of a key in a table of a cliff.toml, e.g. ignore_tags in [git].
+// Only single line basic and literal strings are supported, false is returned if the key is not set to one.
+func Option(config, table, key string) (string, bool) {
+	keyRegex := regexp.MustCompile(`^\s*` + regexp.QuoteMeta(key) + `\s*=\s*(.*)$`)
+
+	inTable := false
+	for _, line := range strings.Split(config, "\n") {
+		if tableRegex.MatchString(line) {
+			if inTable {
+				// reached the next table
+				return "", false
+			}
+			inTable = strings.TrimSpace(strings.Split(line, "#")[0]) == "["+table+"]"
+			continue
+		}
+		if !inTable {
+			continue
+		}
+		match := keyRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		return parseTOMLString(match[1])
+	}
+	return "", false
+}
+
+// parseTOMLString decodes a single line TOML basic or literal string, ignoring a trailing comment.
+func parseTOMLString(value string) (string, bool) {
+	if literal, ok := strings.CutPrefix(value, "'"); ok {
+		s, _, ok := strings.Cut(literal, "'")
+		return s, ok
+	}
+
+	basic, ok := strings.CutPrefix(value, `"`)
+	if !ok {
+		return "", false
+	}
+	var sb strings.Builder
+	for i := 0; i < len(basic); i++ {
+		switch c := basic[i]; c {
+		case '"':
+			return sb.String(), true
+		case '\\':
+			i++
+			if i == len(basic) {
+				return "", false
+			}
+			switch basic[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				// \\ and \"
+				sb.WriteByte(basic[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+// TOMLString encodes a TOML basic string.
+func TOMLString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// TOMLStringArray encodes a TOML array of basic strings.
+func TOMLStringArray(s []string) string {
+	values := make([]string, 0, len(s))
+	for _, v := range s {
+		values = append(values, TOMLString(v))
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
@@ -0,0 +1,133 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmojiGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "Plain Group",
+			config: `{ message = "^feat", group = "Features" },`,
+			want:   `{ message = "^feat", group = "<!-- 0 -->🚀 Features" },`,
+		},
+		{name: "Existing Emoji",
+			config: `{ message = "^fix", group = "<!-- 1 -->🐛  Bug Fixes" },`,
+			want:   `{ message = "^fix", group = "<!-- 1 -->🐛 Bug Fixes" },`,
+		},
+		{name: "Keep a Changelog Group",
+			config: `{ message = "^fix", group = "Fixed" },`,
+			want:   `{ message = "^fix", group = "<!-- 1 -->🐛 Fixed" },`,
+		},
+		{name: "Unknown Group",
+			config: `{ message = "^wip", group = "Work In Progress" },`,
+			want:   `{ message = "^wip", group = "Work In Progress" },`,
+		},
+		{name: "Group Header",
+			config: `### {{ group | upper_first }}`,
+			want:   `### {{ group | striptags | trim | upper_first }}`,
+		},
+		{name: "Group Header Without Filters",
+			config: `### {{ group }}`,
+			want:   `### {{ group | striptags | trim }}`,
+		},
+		{name: "Group Header Already Stripped",
+			config: `### {{ group | striptags | trim | upper_first }}`,
+			want:   `### {{ group | striptags | trim | upper_first }}`,
+		},
+		{name: "Group By",
+			config: `{% for group, commits in commits | group_by(attribute="group") %}`,
+			want:   `{% for group, commits in commits | group_by(attribute="group") %}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, EmojiGroups(tt.config))
+		})
+	}
+}
+
+func Test_SetOption(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "Add To Table",
+			config: "[changelog]\ntrim = true\n\n[git]\nconventional_commits = true\n",
+			want:   "[changelog]\ntrim = true\n\n[git]\ntag_pattern = \"v.*\"\nconventional_commits = true\n",
+		},
+		{name: "Replace Existing",
+			config: "[git]\ntag_pattern = \"old\"\nconventional_commits = true\n",
+			want:   "[git]\ntag_pattern = \"v.*\"\nconventional_commits = true\n",
+		},
+		{name: "Replace Commented",
+			config: "[git]\n# tag_pattern = \"v[0-9].*\"\nconventional_commits = true\n",
+			want:   "[git]\ntag_pattern = \"v.*\"\nconventional_commits = true\n",
+		},
+		{name: "Ignore Other Tables",
+			config: "[bump]\ntag_pattern = \"other\"\n\n[git]\nconventional_commits = true\n",
+			want:   "[bump]\ntag_pattern = \"other\"\n\n[git]\ntag_pattern = \"v.*\"\nconventional_commits = true\n",
+		},
+		{name: "Add Table",
+			config: "[changelog]\ntrim = true\n",
+			want:   "[changelog]\ntrim = true\n\n[git]\ntag_pattern = \"v.*\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SetOption(tt.config, "git", "tag_pattern", `"v.*"`))
+		})
+	}
+}
+
+func Test_Option(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+		found  bool
+	}{
+		{name: "Basic String",
+			config: "[git]\nignore_tags = \"v[0-9]+\\\\.0-beta\" # betas\n",
+			want:   `v[0-9]+\.0-beta`,
+			found:  true,
+		},
+		{name: "Literal String",
+			config: "[git]\nignore_tags = 'v[0-9]+\\.0-beta'\n",
+			want:   `v[0-9]+\.0-beta`,
+			found:  true,
+		},
+		{name: "Commented",
+			config: "[git]\n# ignore_tags = \"beta\"\n",
+		},
+		{name: "Other Table",
+			config: "[bump]\nignore_tags = \"beta\"\n\n[git]\nconventional_commits = true\n",
+		},
+		{name: "Not A String",
+			config: "[git]\nignore_tags = 1\n",
+		},
+		{name: "Missing Table",
+			config: "[changelog]\ntrim = true\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := Option(tt.config, "git", "ignore_tags")
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_TOMLString(t *testing.T) {
+	assert.Equal(t, `"my-module/v[0-9]+\\.[0-9]+\\.[0-9]+$"`, TOMLString(`my-module/v[0-9]+\.[0-9]+\.[0-9]+$`))
+	assert.Equal(t, `"say \"hi\"\n"`, TOMLString("say \"hi\"\n"))
+	assert.Equal(t, `["a/**", "b/**"]`, TOMLStringArray([]string{"a/**", "b/**"}))
+	assert.Equal(t, `[]`, TOMLStringArray(nil))
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PullRequest is a pull request or merge request fetched from a remote.
+type PullRequest struct {
+	Number int
+	Title  string
+	// Username of the author.
+	Author string
+	// Whether this is the author's first contribution to the repository.
+	FirstTime bool
+}
+
+// RemoteNotes renders a "Pull Requests" section listing each pull request once, ordered by number,
+// and a "New Contributors" section listing the first pull request of each first-time contributor.
+// Pull request numbers are prefixed with prefix, e.g. "#" or "!" for GitLab merge requests.
+// An empty string is returned if there are no pull requests.
+func RemoteNotes(pullRequests []PullRequest, prefix string) string {
+	prs := slices.Clone(pullRequests)
+	prs = slices.DeleteFunc(prs, func(pr PullRequest) bool { return pr.Number == 0 })
+	slices.SortStableFunc(prs, func(a, b PullRequest) int { return a.Number - b.Number })
+	prs = slices.CompactFunc(prs, func(a, b PullRequest) bool { return a.Number == b.Number })
+	if len(prs) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### Pull Requests\n\n")
+	for _, pr := range prs {
+		fmt.Fprintf(&sb, "- %s%d %s", prefix, pr.Number, strings.TrimSpace(pr.Title))
+		if pr.Author != "" {
+			fmt.Fprintf(&sb, " by @%s", pr.Author)
+		}
+		sb.WriteString("\n")
+	}
+
+	seen := map[string]bool{}
+	var contributors []string
+	for _, pr := range prs {
+		if !pr.FirstTime || pr.Author == "" || seen[pr.Author] {
+			continue
+		}
+		seen[pr.Author] = true
+		contributors = append(contributors, fmt.Sprintf("- @%s made their first contribution in %s%d\n", pr.Author, prefix, pr.Number))
+	}
+	if len(contributors) != 0 {
+		sb.WriteString("\n### New Contributors\n\n")
+		sb.WriteString(strings.Join(contributors, ""))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RemoteNotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		pullRequests []PullRequest
+		prefix       string
+		want         string
+	}{
+		{name: "No Pull Requests",
+			pullRequests: []PullRequest{{Title: "fix: not merged through a pull request"}},
+			prefix:       "#",
+			want:         "",
+		},
+		{name: "Ordered And Deduplicated",
+			pullRequests: []PullRequest{
+				{Number: 3, Title: "feat: b", Author: "bob"},
+				{Number: 2, Title: "fix: a", Author: "alice"},
+				{Number: 3, Title: "feat: b", Author: "bob"},
+			},
+			prefix: "#",
+			want:   "### Pull Requests\n\n- #2 fix: a by @alice\n- #3 feat: b by @bob\n",
+		},
+		{name: "New Contributors",
+			pullRequests: []PullRequest{
+				{Number: 5, Title: "fix: c", Author: "carol", FirstTime: true},
+				{Number: 4, Title: "feat: c", Author: "carol", FirstTime: true},
+				{Number: 1, Title: "docs: a", Author: "alice"},
+			},
+			prefix: "#",
+			want: "### Pull Requests\n\n- #1 docs: a by @alice\n- #4 feat: c by @carol\n- #5 fix: c by @carol\n" +
+				"\n### New Contributors\n\n- @carol made their first contribution in #4\n",
+		},
+		{name: "Merge Requests Without Author",
+			pullRequests: []PullRequest{{Number: 7, Title: " fix: d ", FirstTime: true}},
+			prefix:       "!",
+			want:         "### Pull Requests\n\n- !7 fix: d\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RemoteNotes(tt.pullRequests, tt.prefix))
+		})
+	}
+}
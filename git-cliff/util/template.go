@@ -0,0 +1,57 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// headingRegex matches the heading of a release, e.g. "## [1.0.1]".
+	headingRegex = regexp.MustCompile(`^#{1,2} `)
+	// variableRegex matches a valid Tera variable name.
+	variableRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// WithTemplateVar defines a string variable at the start of a Tera template, so it can be
+// rendered anywhere in the template, e.g. "{{ extra_notes }}".
+func WithTemplateVar(template, name, value string) (string, error) {
+	if !variableRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid template variable name %q", name)
+	}
+
+	// Tera string literals have no escape sequences, use a quote not found in the value
+	for _, quote := range []string{"`", `"`, "'"} {
+		if !strings.Contains(value, quote) {
+			return fmt.Sprintf("{%% set %s = %s%s%s %%}", name, quote, value, quote) + template, nil
+		}
+	}
+
+	return "", fmt.Errorf("template variable %s contains every kind of quote, it cannot be used in a template", name)
+}
+
+// UsesTemplateVar reports whether a Tera template references a variable in an expression or a tag,
+// e.g. "{{ extra_notes }}" or "{% if extra_notes %}".
+func UsesTemplateVar(template, name string) bool {
+	re := regexp.MustCompile(`\{[{%][^}]*\b` + regexp.QuoteMeta(name) + `\b`)
+	return re.MatchString(template)
+}
+
+// InsertNotes inserts notes after the first release heading and the blank line following it,
+// or at the start if there is no release heading.
+func InsertNotes(releaseNotes, notes string) string {
+	lines := strings.SplitAfter(releaseNotes, "\n")
+	for i, line := range lines {
+		if !headingRegex.MatchString(line) {
+			continue
+		}
+
+		after := i + 1
+		if after < len(lines) && strings.TrimSpace(lines[after]) == "" {
+			after++
+		}
+		return strings.Join(lines[:after], "") + notes + "\n" + strings.Join(lines[after:], "")
+	}
+
+	return notes + "\n" + releaseNotes
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithTemplateVar(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Backtick Quoted",
+			value: "extra notes",
+			want:  "{% set extra_notes = `extra notes` %}{{ extra_notes }}",
+		},
+		{name: "Contains Backtick",
+			value: "run `make`",
+			want:  "{% set extra_notes = \"run `make`\" %}{{ extra_notes }}",
+		},
+		{name: "Contains Backtick And Double Quote",
+			value: "run `make \"all\"`",
+			want:  "{% set extra_notes = 'run `make \"all\"`' %}{{ extra_notes }}",
+		},
+		{name: "Multiline",
+			value: "line 1\nline 2",
+			want:  "{% set extra_notes = `line 1\nline 2` %}{{ extra_notes }}",
+		},
+		{name: "Every Quote",
+			value:   "`\"'",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WithTemplateVar("{{ extra_notes }}", "extra_notes", tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_UsesTemplateVar(t *testing.T) {
+	assert.True(t, UsesTemplateVar("{{ extra_notes }}", "extra_notes"))
+	assert.True(t, UsesTemplateVar("{% if extra_notes %}{{ extra_notes | trim }}{% endif %}", "extra_notes"))
+	assert.True(t, UsesTemplateVar("{{extra_notes}}", "extra_notes"))
+	assert.False(t, UsesTemplateVar("## {{ version }}", "extra_notes"))
+	assert.False(t, UsesTemplateVar("extra_notes", "extra_notes"))
+	assert.False(t, UsesTemplateVar("{{ my_extra_notes }}", "extra_notes"))
+	assert.False(t, UsesTemplateVar("", "extra_notes"))
+}
+
+func Test_InsertNotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		releaseNotes string
+		want         string
+	}{
+		{name: "Grouped Sections",
+			releaseNotes: "## [1.0.1]\n\n### 🐛 Bug Fixes\n\n- Test tag\n",
+			want:         "## [1.0.1]\n\nextra notes\n### 🐛 Bug Fixes\n\n- Test tag\n",
+		},
+		{name: "No Grouped Sections",
+			releaseNotes: "## [1.0.1]\n\n- Test tag\n",
+			want:         "## [1.0.1]\n\nextra notes\n- Test tag\n",
+		},
+		{name: "No Blank Line",
+			releaseNotes: "## [1.0.1]\n- Test tag\n",
+			want:         "## [1.0.1]\nextra notes\n- Test tag\n",
+		},
+		{name: "Heading Only",
+			releaseNotes: "## [1.0.1]",
+			want:         "## [1.0.1]extra notes\n",
+		},
+		{name: "No Heading",
+			releaseNotes: "- Test tag\n",
+			want:         "extra notes\n- Test tag\n",
+		},
+		{name: "Empty",
+			releaseNotes: "",
+			want:         "extra notes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, InsertNotes(tt.releaseNotes, "extra notes"))
+		})
+	}
+}
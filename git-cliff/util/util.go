@@ -0,0 +1,72 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// releaseRegex matches a version without prerelease or build metadata, optionally prefixed, e.g. my-module/v1.2.3.
+	releaseRegex = regexp.MustCompile(`[0-9]+\.[0-9]+\.[0-9]+$`)
+	// identifierRegex matches a single semver prerelease identifier, e.g. rc.
+	identifierRegex = regexp.MustCompile(`^[0-9A-Za-z-]+$`)
+	// prereleaseRegex matches a version with a prerelease, capturing the release, e.g. my-module/v1.2.3 of my-module/v1.2.3-rc.1.
+	prereleaseRegex = regexp.MustCompile(`^(.*[0-9]+\.[0-9]+\.[0-9]+)-`)
+)
+
+// NextPrerelease generates the next '<version>-<identifier>.<n>' prerelease of a version,
+// where n is one more than the highest existing prerelease of that version, starting at 1.
+//
+//	e.g. NextPrerelease("v1.4.0", "rc", []string{"v1.3.0", "v1.4.0-rc.1"}) returns "v1.4.0-rc.2"
+func NextPrerelease(version, identifier string, existing []string) (string, error) {
+	if !releaseRegex.MatchString(version) {
+		return "", fmt.Errorf("version %q must end with MAJOR.MINOR.PATCH to create a prerelease", version)
+	}
+	if !identifierRegex.MatchString(identifier) {
+		return "", fmt.Errorf("invalid prerelease identifier %q: must only contain [0-9A-Za-z-]", identifier)
+	}
+
+	prefix := version + "-" + identifier + "."
+
+	var latest int
+	for _, tag := range existing {
+		counter, ok := strings.CutPrefix(tag, prefix)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(counter)
+		if err != nil || n < 0 {
+			// e.g. v1.4.0-rc.1.hotfix, not a prerelease counter we created
+			continue
+		}
+		latest = max(latest, n)
+	}
+
+	return prefix + strconv.Itoa(latest+1), nil
+}
+
+// MatchingTags filters tags with a regex, as git-cliff does with tag_pattern.  A prerelease tag also matches
+// if its release does, so a pattern of releases keeps their prereleases.  All tags match an empty pattern.
+func MatchingTags(tags []string, pattern string) ([]string, error) {
+	if pattern == "" {
+		return tags, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+	}
+
+	var matching []string
+	for _, tag := range tags {
+		release := tag
+		if m := prereleaseRegex.FindStringSubmatch(tag); m != nil {
+			release = m[1]
+		}
+		if re.MatchString(tag) || re.MatchString(release) {
+			matching = append(matching, tag)
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,120 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NextPrerelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    string
+		identifier string
+		existing   []string
+		want       string
+		wantErr    bool
+	}{
+		{name: "First Prerelease",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.2.0", "v1.3.0"},
+			want:       "v1.4.0-rc.1",
+		},
+		{name: "No Tags",
+			version:    "v0.1.0",
+			identifier: "rc",
+			existing:   []string{},
+			want:       "v0.1.0-rc.1",
+		},
+		{name: "Next Prerelease",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.3.0", "v1.4.0-rc.1", "v1.4.0-rc.2"},
+			want:       "v1.4.0-rc.3",
+		},
+		{name: "Unordered Prereleases",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.4.0-rc.10", "v1.4.0-rc.9", "v1.4.0-rc.2"},
+			want:       "v1.4.0-rc.11",
+		},
+		{name: "Other Identifier",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.4.0-beta.1", "v1.4.0-beta.2"},
+			want:       "v1.4.0-rc.1",
+		},
+		{name: "Other Version",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.3.0-rc.1", "v1.3.0-rc.2", "v1.3.0"},
+			want:       "v1.4.0-rc.1",
+		},
+		{name: "Non-numeric Counter",
+			version:    "v1.4.0",
+			identifier: "rc",
+			existing:   []string{"v1.4.0-rc.1", "v1.4.0-rc.1.hotfix"},
+			want:       "v1.4.0-rc.2",
+		},
+		{name: "Prefixed Tags",
+			version:    "my-module/v1.4.0",
+			identifier: "rc",
+			existing:   []string{"my-module/v1.4.0-rc.1", "other/v1.4.0-rc.5"},
+			want:       "my-module/v1.4.0-rc.2",
+		},
+		{name: "No v Prefix",
+			version:    "1.4.0",
+			identifier: "alpha",
+			existing:   []string{"1.4.0-alpha.1"},
+			want:       "1.4.0-alpha.2",
+		},
+		// Invalid input
+		{name: "Version Is Prerelease",
+			version:    "v1.4.0-rc.1",
+			identifier: "rc",
+			wantErr:    true,
+		},
+		{name: "Invalid Identifier",
+			version:    "v1.4.0",
+			identifier: "rc.1",
+			wantErr:    true,
+		},
+		{name: "Empty Identifier",
+			version:    "v1.4.0",
+			identifier: "",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextPrerelease(tt.version, tt.identifier, tt.existing)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_MatchingTags(t *testing.T) {
+	tags := []string{"a/v1.0.0", "a/v1.1.0-rc.1", "b/v1.1.0-rc.2"}
+
+	got, err := MatchingTags(tags, `^a/v`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/v1.0.0", "a/v1.1.0-rc.1"}, got)
+
+	got, err = MatchingTags(tags, `^a/v[0-9]+\.[0-9]+\.[0-9]+$`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/v1.0.0", "a/v1.1.0-rc.1"}, got)
+
+	got, err = MatchingTags(tags, "")
+	require.NoError(t, err)
+	assert.Equal(t, tags, got)
+
+	_, err = MatchingTags(tags, `(`)
+	assert.Error(t, err)
+}
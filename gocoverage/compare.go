@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Change in coverage between a baseline and the current results
+type CoverageDelta struct {
+	// Package name, or file and function name, e.g. example.com/foo/foo.go:(*Baz).Bar, empty for the total
+	Name string
+	// Baseline percentage, 0 if added
+	Before float64
+	// Current percentage, 0 if removed
+	After float64
+	// Change in percentage points
+	Change float64
+	// Only in the current results
+	Added bool
+	// Only in the baseline
+	Removed bool
+}
+
+// Comparison of coverage results with a baseline
+type CoverageComparison struct {
+	// Change in total coverage
+	Total *CoverageDelta
+	// Change in coverage of each package, sorted by name
+	Packages []*CoverageDelta
+	// Change in coverage of each function, sorted by name
+	Functions []*CoverageDelta
+	// Functions that lost coverage, largest drop first
+	LostCoverage []*CoverageDelta
+}
+
+// Compare coverage with a baseline, e.g. the coverage of the target branch of a pull request.
+// Functions are compared by file, receiver and name, so functions moved within a file are still compared.
+func (cr *CoverageResults) Compare(ctx context.Context,
+	// baseline coverage results
+	// +optional
+	baseline *CoverageResults,
+
+	// baseline coverage profile in text format (coverage.txt), instead of baseline results.
+	// The excludes of the current results are applied.
+	// +optional
+	baselineProfile *dagger.File,
+
+	// module source of the baseline profile, to find its functions.
+	// Defaults to the current source, which is only accurate for functions that have not moved.
+	// The functions of baseline files missing from the source, e.g. deleted files, are not compared.
+	// +optional
+	baselineSource *dagger.Directory,
+) (*CoverageComparison, error) {
+	switch {
+	case baseline == nil && baselineProfile == nil:
+		return nil, fmt.Errorf("a baseline or a baseline profile is required")
+	case baseline != nil && baselineProfile != nil:
+		return nil, fmt.Errorf("only one of a baseline or a baseline profile can be compared")
+	case baselineProfile != nil:
+		baseline = &CoverageResults{
+			Coverage: cr.Coverage,
+			Text:     baselineProfile,
+		}
+	}
+	if baselineSource == nil {
+		baselineSource = baseline.Coverage.Base.Directory(".")
+	}
+
+	current, currentFuncs, err := cr.functions(ctx, cr.Coverage.Base.Directory("."), false)
+	if err != nil {
+		return nil, err
+	}
+	before, beforeFuncs, err := baseline.functions(ctx, baselineSource, true)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+
+	comparison := util.Compare(before, current, beforeFuncs, currentFuncs)
+	return &CoverageComparison{
+		Total:        coverageDelta(comparison.Total),
+		Packages:     coverageDeltas(comparison.Packages),
+		Functions:    coverageDeltas(comparison.Functions),
+		LostCoverage: coverageDeltas(util.Regressions(comparison.Functions, 0)),
+	}, nil
+}
+
+// functions returns the profiles and the coverage of their functions, found in the module source.
+// With skipMissing, files missing from the source are skipped instead of failing.
+func (cr *CoverageResults) functions(ctx context.Context, src *dagger.Directory, skipMissing bool) ([]*util.Profile, []util.FuncCoverage, error) {
+	profiles, err := cr.profiles(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modulePath, err := cr.Coverage.modulePath(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var funcs []util.FuncCoverage
+	for _, p := range profiles {
+		file := util.TrimModule(p.FileName, modulePath)
+		if skipMissing {
+			exists, err := src.Exists(ctx, file)
+			if err != nil {
+				return nil, nil, fmt.Errorf("checking for %s: %w", p.FileName, err)
+			}
+			if !exists {
+				continue
+			}
+		}
+		contents, err := src.File(file).Contents(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s: %w", p.FileName, err)
+		}
+		fileFuncs, err := util.Functions(p, contents)
+		if err != nil {
+			return nil, nil, err
+		}
+		funcs = append(funcs, fileFuncs...)
+	}
+	return profiles, funcs, nil
+}
+
+func coverageDelta(d util.Delta) *CoverageDelta {
+	return &CoverageDelta{
+		Name:    d.Name,
+		Before:  d.Before,
+		After:   d.After,
+		Change:  d.Change(),
+		Added:   d.Added,
+		Removed: d.Removed,
+	}
+}
+
+func coverageDeltas(deltas []util.Delta) []*CoverageDelta {
+	result := make([]*CoverageDelta, 0, len(deltas))
+	for _, d := range deltas {
+		result = append(result, coverageDelta(d))
+	}
+	return result
+}
+
+// comparison converts back to the util type
+func (cc *CoverageComparison) comparison() *util.Comparison {
+	deltas := func(ds []*CoverageDelta) []util.Delta {
+		result := make([]util.Delta, 0, len(ds))
+		for _, d := range ds {
+			result = append(result, d.delta())
+		}
+		return result
+	}
+	return &util.Comparison{
+		Total:     cc.Total.delta(),
+		Packages:  deltas(cc.Packages),
+		Functions: deltas(cc.Functions),
+	}
+}
+
+func (d *CoverageDelta) delta() util.Delta {
+	return util.Delta{
+		Name:    d.Name,
+		Before:  d.Before,
+		After:   d.After,
+		Added:   d.Added,
+		Removed: d.Removed,
+	}
+}
+
+// Check that neither the total coverage nor the coverage of any package dropped
+func (cc *CoverageComparison) CheckNoRegression(
+	// percentage points the coverage may drop by
+	// +optional
+	tolerance float64,
+) error {
+	var errs []error
+	if cc.Total.delta().Regressed(tolerance) {
+		errs = append(errs, fmt.Errorf("Code coverage dropped from %.2f%% to %.2f%%", cc.Total.Before, cc.Total.After))
+	}
+	for _, pkg := range cc.Packages {
+		if pkg.delta().Regressed(tolerance) {
+			errs = append(errs, fmt.Errorf("Code coverage of %s dropped from %.2f%% to %.2f%%", pkg.Name, pkg.Before, pkg.After))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if len(cc.LostCoverage) != 0 {
+		lost := make([]string, 0, len(cc.LostCoverage))
+		for _, fn := range cc.LostCoverage {
+			lost = append(lost, fmt.Sprintf("%s: %.2f%% -> %.2f%%", fn.Name, fn.Before, fn.After))
+		}
+		errs = append(errs, fmt.Errorf("functions that lost coverage:\n%s", strings.Join(lost, "\n")))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Coverage of the lines changed since a base revision
+type DiffCoverage struct {
+	// Number of changed lines with statements
+	Lines int
+	// Number of changed lines with statements executed at least once
+	Covered int
+	// Percent of changed lines covered, 100 if no changed line has statements
+	Percent float64
+	// Ranges of uncovered changed lines, e.g. "internal/foo.go:12-14"
+	Uncovered []string
+}
+
+// Coverage of the lines added or modified since a base revision, e.g. the target branch of a pull request
+func (cr *CoverageResults) DiffCoverage(ctx context.Context,
+	// base revision to compare the source in the base container to
+	base *dagger.GitRef,
+
+	// directory of the go.mod file in the base revision
+	// +optional
+	// +default="."
+	path string,
+) (*DiffCoverage, error) {
+	profiles, err := cr.profiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, err := cr.Coverage.modulePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	src := cr.Coverage.Base.Directory(".")
+	baseSrc := base.Tree().Directory(path)
+	changes := src.Changes(baseSrc)
+	added, err := changes.AddedPaths(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing added files: %w", err)
+	}
+	modified, err := changes.ModifiedPaths(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing modified files: %w", err)
+	}
+
+	changed := map[string][]int{}
+	for _, file := range append(added, modified...) {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		contents, err := src.File(file).Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// every line of an added file is changed
+		baseContents := ""
+		if slices.Contains(modified, file) {
+			if baseContents, err = baseSrc.File(file).Contents(ctx); err != nil {
+				return nil, err
+			}
+		}
+		changed[file] = util.ChangedLines(baseContents, contents)
+	}
+
+	result := util.DiffCoverage(profiles, modulePath, changed)
+	return &DiffCoverage{
+		Lines:     result.Lines,
+		Covered:   result.Covered,
+		Percent:   result.Percent(),
+		Uncovered: result.Uncovered,
+	}, nil
+}
+
+// Check that the coverage of the changed lines is above a threshold
+func (dc *DiffCoverage) Check(
+	// minimum percentage to accept
+	threshold float64,
+) error {
+	if dc.Percent < threshold {
+		return fmt.Errorf("Code coverage of %.2f%% of the changed lines is insufficient (need at least %.2f%%), uncovered lines:\n%s",
+			dc.Percent, threshold, strings.Join(dc.Uncovered, "\n"))
+	}
+	return nil
+}
@@ -5,13 +5,15 @@ package main
 import (
 	"context"
 	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"errors"
 	"fmt"
 	"math"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-
-	"al.essio.dev/pkg/shellescape"
+	"sync"
 )
 
 // Code coverage generator
@@ -21,6 +23,12 @@ type GoCoverage struct {
 
 	// +private
 	Excludes []string
+
+	// +private
+	ExcludeGlobs []string
+
+	// +private
+	ExcludeGenerated bool
 }
 
 func New(
@@ -29,19 +37,85 @@ func New(
 	// - go tool works for go-cover-treemap (optional)
 	base *dagger.Container,
 
-	// Exclude files from coverage
+	// Exclude files from coverage, regular expressions matched against the file import path, e.g. `\.pb\.go$`
 	// +optional
 	excludes []string,
-) *GoCoverage {
-	return &GoCoverage{
-		Base:     base,
-		Excludes: excludes,
+
+	// Exclude files from coverage, glob patterns matched against the file import path or the path
+	// relative to the module root. "*" matches within a path element and "**" matches any number of elements,
+	// e.g. "internal/mocks/**"
+	// +optional
+	excludeGlobs []string,
+
+	// Exclude generated files, with a "// Code generated ... DO NOT EDIT." comment before the package clause
+	// +optional
+	excludeGenerated bool,
+) (*GoCoverage, error) {
+	// fail early on invalid excludes
+	if _, err := util.NewExcludes(excludes, excludeGlobs); err != nil {
+		return nil, err
 	}
+
+	return &GoCoverage{
+		Base:             base,
+		Excludes:         excludes,
+		ExcludeGlobs:     excludeGlobs,
+		ExcludeGenerated: excludeGenerated,
+	}, nil
 }
 
+// Mode of counting statement executions in unit tests, see `go help testflag`
+type CoverMode string
+
+const (
+	// Whether each statement ran
+	CoverModeSet CoverMode = "SET"
+	// How many times each statement ran
+	CoverModeCount CoverMode = "COUNT"
+	// How many times each statement ran, correct in multithreaded tests.  The default with race
+	CoverModeAtomic CoverMode = "ATOMIC"
+)
+
 // Code coverage from unit tests
 func (m *GoCoverage) UnitTests(
 	ctx context.Context,
+
+	// package patterns to test, defaults to all packages of the module, e.g. "./internal/..."
+	// +optional
+	packages []string,
+
+	// build tags
+	// +optional
+	tags []string,
+
+	// enable the data race detector, requires cgo
+	// +optional
+	race bool,
+
+	// coverage mode, defaults to set or atomic with race
+	// +optional
+	coverMode CoverMode,
+
+	// only run tests matching the regular expression, see `go test -run`
+	// +optional
+	run string,
+
+	// skip tests matching the regular expression, see `go test -skip`
+	// +optional
+	skip string,
+
+	// extra arguments for go test, added after the packages, e.g. ["-count=2", "-timeout=5m"]
+	// +optional
+	args []string,
+
+	// split the packages across this number of containers, running in parallel, and merge the results
+	// +optional
+	// +default=1
+	shards int,
+
+	// collect partial coverage when tests fail instead of returning an error.  See TestSummary for the failures
+	// +optional
+	allowFailures bool,
 ) (*CoverageResults, error) {
 	// produce binary coverage results instead of the traditional textual format
 	// see https://github.com/thediveo/lxkns/blob/cef5a31d7517cb126378f81628f51672cb793527/scripts/cov.sh#L28
@@ -56,22 +130,145 @@ func (m *GoCoverage) UnitTests(
 			Directory(covDir)
 	*/
 
-	// TODO use golang (don't shell out)
-	pkg, err := m.Base.WithExec([]string{"go", "list", "-m"}).Stdout(ctx)
+	pkg, err := m.modulePath(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get the module name: %w: ", err)
+		return nil, err
+	}
+
+	if len(packages) == 0 {
+		packages = []string{"./..."}
+	}
+
+	var buildFlags []string
+	if len(tags) != 0 {
+		buildFlags = append(buildFlags, "-tags", strings.Join(tags, ","))
+	}
+	if race {
+		buildFlags = append(buildFlags, "-race")
 	}
-	pkg = strings.TrimSpace(pkg)
 
-	text := m.Base.
+	flags := append([]string{"-trimpath", "-coverpkg", pkg + "/..."}, buildFlags...)
+	if coverMode != "" {
+		flags = append(flags, "-covermode", strings.ToLower(string(coverMode)))
+	}
+	if run != "" {
+		flags = append(flags, "-run", run)
+	}
+	if skip != "" {
+		flags = append(flags, "-skip", skip)
+	}
+
+	var ctrs []*dagger.Container
+	if shards <= 1 {
+		ctrs = append(ctrs, m.unitTests(flags, packages, args))
+	} else {
+		list := append(append([]string{"go", "list"}, buildFlags...), packages...)
+		out, err := m.Base.WithExec(list).Stdout(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the packages: %w", err)
+		}
+		pkgs := strings.Fields(out)
+		if len(pkgs) == 0 {
+			return nil, fmt.Errorf("no packages match %s", strings.Join(packages, " "))
+		}
+		for _, shard := range util.Shard(pkgs, shards) {
+			ctrs = append(ctrs, m.unitTests(flags, shard, args))
+		}
+
+		// run the shards in parallel, instead of one at a time while checking the results
+		dir := dag.Directory()
+		for i, ctr := range ctrs {
+			dir = dir.WithFile(fmt.Sprintf("%d.json", i), ctr.File("/test.json"))
+		}
+		if _, err := dir.Sync(ctx); err != nil {
+			return nil, fmt.Errorf("unable to run the unit tests: %w", err)
+		}
+	}
+
+	results := make([]*CoverageResults, 0, len(ctrs))
+	for _, ctr := range ctrs {
+		if !allowFailures {
+			if err := testFailure(ctx, ctr); err != nil {
+				return nil, err
+			}
+		}
+		if err := missingProfile(ctx, ctr); err != nil {
+			return nil, err
+		}
+		results = append(results, &CoverageResults{
+			Coverage: m,
+			Text:     ctr.File("/raw.txt"),
+			TestJSON: ctr.File("/test.json"),
+		})
+	}
+	if len(results) == 1 {
+		return results[0], nil
+	}
+
+	return results[0].mergeText(ctx, results, nil)
+}
+
+// unitTests runs go test for the packages with text format coverage in /raw.txt and go test -json output
+// in /test.json, also when tests fail
+func (m *GoCoverage) unitTests(flags []string, packages []string, args []string) *dagger.Container {
+	cmd := append(append([]string{"go", "test", "-json"}, flags...), "-coverprofile", "/raw.txt")
+	cmd = append(append(cmd, packages...), args...)
+
+	return m.Base.
 		WithDirectory("coverage", dag.Directory()).
-		WithExec([]string{"go", "test", "-trimpath", "./...", "-coverprofile", "/raw.txt", "-coverpkg", pkg + "/..."}).
-		File("/raw.txt")
+		WithExec(cmd, dagger.ContainerWithExecOpts{RedirectStdout: "/test.json", Expect: dagger.ReturnTypeAny})
+}
 
-	return &CoverageResults{
-		Coverage: m,
-		Text:     text,
-	}, nil
+// testFailure describes the failed tests if go test failed
+func testFailure(ctx context.Context, ctr *dagger.Container) error {
+	code, err := ctr.ExitCode(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to run the unit tests: %w", err)
+	}
+	if code == 0 {
+		return nil
+	}
+
+	output, err := ctr.File("/test.json").Contents(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the test results: %w", err)
+	}
+	pkgs, err := util.ParseTestEvents(output)
+	if err != nil {
+		return err
+	}
+
+	summary := util.SummarizeTests(pkgs, 0)
+	if summary.Failed == 0 {
+		stderr, err := ctr.Stderr(ctx)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("go test failed with exit code %d: %s", code, stderr)
+	}
+
+	var sb strings.Builder
+	for _, f := range summary.Failures {
+		fmt.Fprintf(&sb, "--- FAIL: %s %s\n%s", f.Package, f.Name, f.Output)
+	}
+	return fmt.Errorf("%d unit test(s) failed:\n%s", summary.Failed, sb.String())
+}
+
+// missingProfile returns an error if go test did not write /raw.txt, e.g. because no package could be built
+func missingProfile(ctx context.Context, ctr *dagger.Container) error {
+	exists, err := ctr.Exists(ctx, "/raw.txt")
+	if err != nil {
+		return fmt.Errorf("unable to run the unit tests: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	err = testFailure(ctx, ctr)
+	if err == nil {
+		err = errors.New("no packages were tested")
+	}
+	return fmt.Errorf("go test did not write a coverage profile: %w", err)
 }
 
 // Run a go package with coverage
@@ -80,9 +277,8 @@ func (m *GoCoverage) Exec(ctx context.Context,
 	args []string,
 ) (*CoverageResults, error) {
 	const covDir = "/coverage"
-	raw := m.Base.
+	raw := m.build(pkg).
 		WithDirectory(covDir, dag.Directory()).
-		WithExec([]string{"go", "build", "-trimpath", "-cover", "-o", "/app", pkg}).
 		WithEnvVariable("GOCOVERDIR", covDir).
 		WithExec(append([]string{"/app"}, args...)).
 		Directory(covDir)
@@ -93,6 +289,12 @@ func (m *GoCoverage) Exec(ctx context.Context,
 	}, nil
 }
 
+// build a go package with coverage instrumentation to /app
+func (m *GoCoverage) build(pkg string) *dagger.Container {
+	return m.Base.
+		WithExec([]string{"go", "build", "-trimpath", "-cover", "-o", "/app", pkg})
+}
+
 // Code coverage results
 type CoverageResults struct {
 	// +private
@@ -105,6 +307,13 @@ type CoverageResults struct {
 	// Coverage results in older format (text format)
 	// +private
 	Text *dagger.File
+
+	// go test -json output of unit tests
+	// +private
+	TestJSON *dagger.File
+
+	// filtered text format coverage, reused by the reports of a call
+	filtered *dagger.File
 }
 
 func (cr *CoverageResults) withRaw(raw *dagger.Directory) *CoverageResults {
@@ -114,36 +323,117 @@ func (cr *CoverageResults) withRaw(raw *dagger.Directory) *CoverageResults {
 	}
 }
 
-// Merge all results
+// Merge all results.
+// Binary results are merged with `go tool covdata merge`. If any results are in text format, all results
+// are merged as text, taking the highest count of a block in set mode and the sum in count and atomic mode.
 func (cr *CoverageResults) Merge(ctx context.Context,
 	// other coverage results to merge into the returned results
-	other *CoverageResults,
+	// +optional
+	others []*CoverageResults,
+
+	// binary coverage directories to merge, e.g. a GOCOVERDIR
+	// +optional
+	raw []*dagger.Directory,
+
+	// text format coverage profiles to merge, e.g. from `go test -coverprofile`
+	// +optional
+	profiles []*dagger.File,
 
 	// module paths to include
 	// +optional
 	pkgs []string,
 ) (*CoverageResults, error) {
-	if cr.Text != nil {
-		return nil, fmt.Errorf("Coverage results has older format.  Merge is not supported.")
+	all := append([]*CoverageResults{cr}, others...)
+	for _, dir := range raw {
+		all = append(all, cr.withRaw(dir))
+	}
+	for _, file := range profiles {
+		all = append(all, &CoverageResults{Coverage: cr.Coverage, Text: file})
+	}
+
+	if slices.ContainsFunc(all, func(r *CoverageResults) bool { return r.Text != nil }) {
+		return cr.mergeText(ctx, all, pkgs)
+	}
+
+	inputs := make([]string, 0, len(all))
+	merge := cr.Coverage.Base.WithDirectory("/merged", dag.Directory())
+	for i, r := range all {
+		input := fmt.Sprintf("/input/%d", i)
+		inputs = append(inputs, input)
+		merge = merge.WithDirectory(input, r.Raw)
 	}
 
-	args := []string{"go", "tool", "covdata", "merge", "-i", "/left,/right", "-o", "/merged"}
+	args := []string{"go", "tool", "covdata", "merge", "-i", strings.Join(inputs, ","), "-o", "/merged"}
 	if len(pkgs) != 0 {
 		args = append(args, "-pkg", strings.Join(pkgs, ","))
 	}
 
-	raw := cr.Coverage.Base.
-		WithDirectory("/left", cr.Raw).
-		WithDirectory("/right", other.Raw).
-		WithDirectory("/merged", dag.Directory()).
-		WithExec(args).
-		Directory("/merged")
+	return cr.withRaw(merge.WithExec(args).Directory("/merged")), nil
+}
+
+// mergeText merges results in text format
+func (cr *CoverageResults) mergeText(ctx context.Context, all []*CoverageResults, pkgs []string) (*CoverageResults, error) {
+	runs := make([][]*util.Profile, 0, len(all))
+	for _, r := range all {
+		profiles, err := r.profiles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, profiles)
+	}
+
+	merged, err := util.MergeProfiles(runs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 0 {
+		merged = slices.DeleteFunc(merged, func(p *util.Profile) bool {
+			return !slices.ContainsFunc(pkgs, func(pattern string) bool {
+				return util.MatchPackagePattern(pattern, p.Package())
+			})
+		})
+	}
+
+	text := util.FormatProfiles(merged, "set")
+	result := &CoverageResults{
+		Coverage: cr.Coverage,
+		Text:     dag.Directory().WithNewFile("coverage.txt", text).File("coverage.txt"),
+	}
 
-	return cr.withRaw(raw), nil
+	// keep the test results of all unit tests
+	var testJSON []string
+	for _, r := range all {
+		if r.TestJSON == nil {
+			continue
+		}
+		contents, err := r.TestJSON.Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading the test results: %w", err)
+		}
+		testJSON = append(testJSON, contents)
+	}
+	if len(testJSON) != 0 {
+		result.TestJSON = dag.Directory().WithNewFile("test.json", strings.Join(testJSON, "")).File("test.json")
+	}
+
+	return result, nil
 }
 
 // Text format (older style) coverage format
-func (cr *CoverageResults) TextFormat() *dagger.File {
+func (cr *CoverageResults) TextFormat(ctx context.Context) (*dagger.File, error) {
+	if cr.filtered == nil {
+		cov, err := cr.textFormat(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cr.filtered = cov
+	}
+	return cr.filtered, nil
+}
+
+// textFormat converts the results to text format and applies the excludes
+func (cr *CoverageResults) textFormat(ctx context.Context) (*dagger.File, error) {
 	var cov *dagger.File = cr.Text
 
 	// Do the conversion if needed
@@ -155,46 +445,111 @@ func (cr *CoverageResults) TextFormat() *dagger.File {
 	}
 
 	// Filter if needed
-	if len(cr.Coverage.Excludes) != 0 {
-		args := []string{"grep", "-v"}
-		for _, exclude := range cr.Coverage.Excludes {
-			args = append(args, "-e", shellescape.Quote(exclude))
+	excludes, err := util.NewExcludes(cr.Coverage.Excludes, cr.Coverage.ExcludeGlobs)
+	if err != nil {
+		return nil, err
+	}
+	if excludes.Empty() && !cr.Coverage.ExcludeGenerated {
+		return cov, nil
+	}
+
+	text, err := cov.Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the coverage profile: %w", err)
+	}
+
+	modulePath, err := cr.Coverage.modulePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := map[string]bool{}
+	if cr.Coverage.ExcludeGenerated {
+		if generated, err = cr.Coverage.generatedFiles(ctx, text, modulePath); err != nil {
+			return nil, err
 		}
-		args = append(args, "/coverage.txt", ">", "/filtered.txt")
+	}
 
-		cov = cr.Coverage.Base.
-			WithFile("/coverage.txt", cov).
-			WithExec([]string{"sh", "-ec", strings.Join(args, " ")}).
-			File("/filtered.txt")
+	filtered := util.FilterProfile(text, func(fileName string) bool {
+		return generated[fileName] || excludes.Match(fileName, modulePath)
+	})
+
+	return dag.Directory().WithNewFile("coverage.txt", filtered).File("coverage.txt"), nil
+}
+
+// generatedFiles returns the import paths of the generated files in the text format coverage
+func (m *GoCoverage) generatedFiles(ctx context.Context, text, modulePath string) (map[string]bool, error) {
+	profiles, err := util.ParseProfiles(text)
+	if err != nil {
+		return nil, err
+	}
+
+	// read the files concurrently, a profile can have many files
+	src := m.Base.Directory(".")
+	isGenerated := make([]bool, len(profiles))
+	errs := make([]error, len(profiles))
+	var wg sync.WaitGroup
+	for i, p := range profiles {
+		wg.Go(func() {
+			contents, err := src.File(util.TrimModule(p.FileName, modulePath)).Contents(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("reading %s: %w", p.FileName, err)
+				return
+			}
+			isGenerated[i] = util.IsGenerated(contents)
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
 
-	return cov
+	generated := map[string]bool{}
+	for i, p := range profiles {
+		generated[p.FileName] = isGenerated[i]
+	}
+	return generated, nil
 }
 
 // SVG heat map of code coverage
-func (cr *CoverageResults) SVG() *dagger.File {
+func (cr *CoverageResults) SVG(ctx context.Context) (*dagger.File, error) {
+	cov, err := cr.TextFormat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return cr.Coverage.Base.
-		WithFile("/coverage.txt", cr.TextFormat()).
+		WithFile("/coverage.txt", cov).
 		WithExec([]string{"go", "tool", "go-cover-treemap", "-coverprofile", "/coverage.txt"}, dagger.ContainerWithExecOpts{
 			RedirectStdout: "/heat.svg",
 		}).
-		File("/heat.svg")
+		File("/heat.svg"), nil
 }
 
 // HTML report
-func (cr *CoverageResults) HTML() *dagger.File {
+func (cr *CoverageResults) HTML(ctx context.Context) (*dagger.File, error) {
+	cov, err := cr.TextFormat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return cr.Coverage.Base.
-		WithFile("/coverage.txt", cr.TextFormat()).
+		WithFile("/coverage.txt", cov).
 		WithExec([]string{"go", "tool", "cover", "-html", "/coverage.txt", "-o", "/index.html"}).
-		File("/index.html")
+		File("/index.html"), nil
 }
 
 // Summary of coverage by functions
-func (cr *CoverageResults) Summary() *dagger.File {
+func (cr *CoverageResults) Summary(ctx context.Context) (*dagger.File, error) {
+	cov, err := cr.TextFormat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return cr.Coverage.Base.
-		WithFile("/coverage.txt", cr.TextFormat()).
+		WithFile("/coverage.txt", cov).
 		WithExec([]string{"go", "tool", "cover", "-func", "/coverage.txt", "-o", "/summary.txt"}).
-		File("/summary.txt")
+		File("/summary.txt"), nil
 
 	/*
 		return m.Base.
@@ -216,7 +571,11 @@ func (cr *CoverageResults) Percent(ctx context.Context) (float64, error) {
 	// 	return math.NaN(), err
 	// }
 
-	summary, err := cr.Summary().Contents(ctx)
+	summaryFile, err := cr.Summary(ctx)
+	if err != nil {
+		return math.NaN(), err
+	}
+	summary, err := summaryFile.Contents(ctx)
 	if err != nil {
 		return math.NaN(), err
 	}
@@ -238,15 +597,35 @@ func (cr *CoverageResults) Percent(ctx context.Context) (float64, error) {
 func (cr *CoverageResults) Check(ctx context.Context,
 	// minimum percentage to accept
 	threshold float64,
+
+	// minimum percentage of the packages matching a glob pattern, in the form "<pattern>=<percent>".
+	// "*" matches within a path element and "**" matches any number of elements, e.g. "example.com/foo/**=80"
+	// +optional
+	packages []string,
 ) error {
+	minimums, err := util.ParseMinimums(packages)
+	if err != nil {
+		return err
+	}
+
 	percent, err := cr.Percent(ctx)
 	if err != nil {
 		return err
 	}
+	// report the total and every package below its minimum at once
+	var errs []error
 	if percent < threshold {
-		return fmt.Errorf("Code coverage of %.2f%% is insufficient (need at least %.2f%%)", percent, threshold)
+		errs = append(errs, fmt.Errorf("Code coverage of %.2f%% is insufficient (need at least %.2f%%)", percent, threshold))
+	}
+
+	if len(minimums) != 0 {
+		profiles, err := cr.profiles(ctx)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, util.CheckPackages(util.Packages(profiles), minimums))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // Get a directory of all the results
@@ -256,11 +635,27 @@ func (cr *CoverageResults) Directory(ctx context.Context) (*dagger.Directory, er
 		return nil, err
 	}
 
+	cov, err := cr.TextFormat(ctx)
+	if err != nil {
+		return nil, err
+	}
+	svg, err := cr.SVG(ctx)
+	if err != nil {
+		return nil, err
+	}
+	html, err := cr.HTML(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := cr.Summary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return dag.Directory().
-			WithFile("heat.svg", cr.SVG()).
-			WithFile("index.html", cr.HTML()).
-			WithFile("coverage.txt", cr.TextFormat()).
-			WithFile("summary.txt", cr.Summary()).
-			WithNewFile("percent", strconv.FormatFloat(percent, 'f', 2, 64)),
-		nil
+		WithFile("heat.svg", svg).
+		WithFile("index.html", html).
+		WithFile("coverage.txt", cov).
+		WithFile("summary.txt", summary).
+		WithNewFile("percent", strconv.FormatFloat(percent, 'f', 2, 64)), nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+)
+
+// Markdown report with the total coverage, coverage of each package and the least covered functions,
+// e.g. to comment on a pull request. With a baseline, the change in coverage is included.
+func (cr *CoverageResults) Markdown(ctx context.Context,
+	// baseline coverage results
+	// +optional
+	baseline *CoverageResults,
+
+	// baseline coverage profile in text format (coverage.txt), instead of baseline results
+	// +optional
+	baselineProfile *dagger.File,
+
+	// module source of the baseline profile, see Compare
+	// +optional
+	baselineSource *dagger.Directory,
+
+	// number of least covered functions to list
+	// +optional
+	// +default=10
+	functions int,
+
+	// maximum size of the report in bytes, rows are dropped to fit. Defaults to the limit of GitHub comments,
+	// GitLab comments allow 1000000
+	// +optional
+	// +default=65536
+	limit int,
+) (*dagger.File, error) {
+	profiles, funcs, err := cr.functions(ctx, cr.Coverage.Base.Directory("."), false)
+	if err != nil {
+		return nil, err
+	}
+
+	report := util.MarkdownReport{
+		Profiles:     profiles,
+		Functions:    funcs,
+		LeastCovered: functions,
+	}
+
+	if baseline != nil || baselineProfile != nil {
+		comparison, err := cr.Compare(ctx, baseline, baselineProfile, baselineSource)
+		if err != nil {
+			return nil, err
+		}
+		report.Comparison = comparison.comparison()
+	}
+
+	return dag.Directory().WithNewFile("coverage.md", util.Markdown(report, limit)).File("coverage.md"), nil
+}
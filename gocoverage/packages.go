@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/util"
+	"fmt"
+)
+
+// Statement coverage of a package
+type PackageCoverage struct {
+	// Import path of the package
+	Package string
+	// Number of statements
+	Statements int
+	// Number of statements executed at least once
+	Covered int
+	// Percent of statements covered
+	Percent float64
+}
+
+// Statement coverage of each package, sorted by import path
+func (cr *CoverageResults) Packages(ctx context.Context) ([]*PackageCoverage, error) {
+	profiles, err := cr.profiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := util.Packages(profiles)
+	result := make([]*PackageCoverage, 0, len(pkgs))
+	for _, pc := range pkgs {
+		result = append(result, &PackageCoverage{
+			Package:    pc.Package,
+			Statements: pc.Statements,
+			Covered:    pc.Covered,
+			Percent:    pc.Percent(),
+		})
+	}
+	return result, nil
+}
+
+// profiles parses the text format coverage
+func (cr *CoverageResults) profiles(ctx context.Context) ([]*util.Profile, error) {
+	cov, err := cr.TextFormat(ctx)
+	if err != nil {
+		return nil, err
+	}
+	text, err := cov.Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the coverage profile: %w", err)
+	}
+	return util.ParseProfiles(text)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"fmt"
+)
+
+// Cobertura XML report, e.g. for GitLab merge request coverage visualization.
+// File paths are relative to the module root.
+func (cr *CoverageResults) Cobertura(ctx context.Context) (*dagger.File, error) {
+	return cr.report(ctx, "cobertura.xml", util.Cobertura)
+}
+
+// LCOV tracefile, e.g. for Codecov or genhtml.
+// File paths are relative to the module root.
+func (cr *CoverageResults) Lcov(ctx context.Context) (*dagger.File, error) {
+	return cr.report(ctx, "lcov.info", func(profiles []*util.Profile, modulePath string) (string, error) {
+		return util.Lcov(profiles, modulePath), nil
+	})
+}
+
+// SonarQube generic test coverage report, see sonar.coverageReportPaths.
+// File paths are relative to the module root.
+func (cr *CoverageResults) SonarGeneric(ctx context.Context) (*dagger.File, error) {
+	return cr.report(ctx, "sonar-coverage.xml", util.SonarGeneric)
+}
+
+// Coverage badge in the flat shields.io style, e.g. for a README.  Rendered without network access.
+func (cr *CoverageResults) Badge(ctx context.Context,
+	// text on the left of the badge
+	// +optional
+	// +default="coverage"
+	label string,
+
+	// color of the badge from a minimum percentage, in the form "<percent>=<color>".  The color is a shields.io
+	// color name or a hex color, e.g. "80=green" or "80=#97ca00".  Red below every threshold.
+	// Defaults to red below 50%, then orange, yellow, yellowgreen and green in steps of 10%, and brightgreen from 90%
+	// +optional
+	thresholds []string,
+) (*dagger.File, error) {
+	if len(thresholds) == 0 {
+		thresholds = util.DefaultBadgeThresholds
+	}
+	parsed, err := util.ParseBadgeThresholds(thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	percent, err := cr.Percent(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	badge := util.Badge(label, percent, util.BadgeColor(percent, parsed))
+	return dag.Directory().WithNewFile("badge.svg", badge).File("badge.svg"), nil
+}
+
+// report converts the text format coverage, with excludes applied, to another format
+func (cr *CoverageResults) report(ctx context.Context,
+	name string,
+	convert func(profiles []*util.Profile, modulePath string) (string, error),
+) (*dagger.File, error) {
+	profiles, err := cr.profiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, err := cr.Coverage.modulePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := convert(profiles, modulePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Directory().WithNewFile(name, contents).File(name), nil
+}
+
+// modulePath of the go.mod file in the working directory of the base container
+func (m *GoCoverage) modulePath(ctx context.Context) (string, error) {
+	gomod, err := m.Base.File("go.mod").Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("unable to read go.mod: %w", err)
+	}
+	return util.ModulePath(gomod)
+}
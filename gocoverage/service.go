@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"dagger/coverage/internal/dagger"
+	"fmt"
+	"time"
+)
+
+// A coverage instrumented go package running as a service, e.g. for integration or end-to-end tests
+type CoverageService struct {
+	// +private
+	Coverage *GoCoverage
+
+	// Service running the instrumented binary.
+	// The binary must exit normally on SIGTERM (e.g. return from main) to write its coverage.
+	Service *dagger.Service
+
+	// Cache volume mounted as GOCOVERDIR
+	// +private
+	Volume *dagger.CacheVolume
+}
+
+// Run a go package with coverage as a service.
+// Start the service, run the tests against it, then collect the coverage with Results.
+// +cache="never"
+func (m *GoCoverage) Service(
+	// package to build, e.g. "./cmd/server"
+	pkg string,
+
+	// arguments to run the binary with
+	// +optional
+	args []string,
+
+	// ports to expose
+	// +optional
+	ports []int,
+) *CoverageService {
+	// a new volume for every service so coverage of earlier runs is not collected
+	volume := dag.CacheVolume("go-coverage-" + rand.Text())
+
+	ctr := m.build(pkg).
+		WithMountedCache(serviceCovDir, volume).
+		WithEnvVariable("GOCOVERDIR", serviceCovDir)
+	for _, port := range ports {
+		ctr = ctr.WithExposedPort(port)
+	}
+
+	return &CoverageService{
+		Coverage: m,
+		Service:  ctr.AsService(dagger.ContainerAsServiceOpts{Args: append([]string{"/app"}, args...)}),
+		Volume:   volume,
+	}
+}
+
+const serviceCovDir = "/coverage"
+
+// Stop the service gracefully and collect its coverage
+// +cache="never"
+func (cs *CoverageService) Results(ctx context.Context) (*CoverageResults, error) {
+	if _, err := cs.Service.Stop(ctx); err != nil {
+		return nil, fmt.Errorf("stopping the service: %w", err)
+	}
+
+	// the cache volume is not part of the cache key, always copy its current contents
+	raw := cs.Coverage.Base.
+		WithMountedCache(serviceCovDir, cs.Volume).
+		WithEnvVariable("CACHEBUSTER", time.Now().String()).
+		WithExec([]string{"cp", "-R", serviceCovDir + "/.", "/raw"}).
+		Directory("/raw")
+
+	entries, err := raw.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("collecting the coverage of the service: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the service did not write any coverage, it must exit normally when stopped")
+	}
+
+	return &CoverageResults{
+		Coverage: cs.Coverage,
+		Raw:      raw,
+	}, nil
+}
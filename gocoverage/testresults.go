@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"fmt"
+)
+
+// Result of a unit test
+type TestCase struct {
+	// Import path of the package
+	Package string
+	// Name of the test, e.g. TestFoo or TestFoo/subtest.  "[build failed]" or "[setup failed]" for a package
+	// that failed outside of a test
+	Name string
+	// One of pass, fail or skip
+	Result string
+	// Elapsed time in seconds
+	Seconds float64
+	// Output of the test
+	Output string
+}
+
+// Summary of the unit test results
+type TestSummary struct {
+	// Number of passed tests
+	Passed int
+	// Number of failed tests, including packages that failed outside of a test, e.g. to build
+	Failed int
+	// Number of skipped tests
+	Skipped int
+	// Failed tests
+	Failures []*TestCase
+	// Slowest tests, slowest first
+	Slowest []*TestCase
+}
+
+// Unit test results in JUnit XML format
+func (cr *CoverageResults) Junit(ctx context.Context) (*dagger.File, error) {
+	pkgs, err := cr.testPackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	junit, err := util.JUnit(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Directory().WithNewFile("junit.xml", junit).File("junit.xml"), nil
+}
+
+// Summary of the unit test results
+func (cr *CoverageResults) TestSummary(ctx context.Context,
+	// number of slowest tests to include
+	// +optional
+	// +default=10
+	slowest int,
+) (*TestSummary, error) {
+	pkgs, err := cr.testPackages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := util.SummarizeTests(pkgs, slowest)
+	return &TestSummary{
+		Passed:   s.Passed,
+		Failed:   s.Failed,
+		Skipped:  s.Skipped,
+		Failures: testCases(s.Failures),
+		Slowest:  testCases(s.Slowest),
+	}, nil
+}
+
+// testPackages parses the go test -json output
+func (cr *CoverageResults) testPackages(ctx context.Context) ([]*util.TestPackage, error) {
+	if cr.TestJSON == nil {
+		return nil, fmt.Errorf("test results are only available for unit tests")
+	}
+
+	output, err := cr.TestJSON.Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the test results: %w", err)
+	}
+	return util.ParseTestEvents(output)
+}
+
+func testCases(tcs []util.TestCase) []*TestCase {
+	result := make([]*TestCase, 0, len(tcs))
+	for _, tc := range tcs {
+		result = append(result, &TestCase{
+			Package: tc.Package,
+			Name:    tc.Name,
+			Result:  tc.Result,
+			Seconds: tc.Elapsed,
+			Output:  tc.Output,
+		})
+	}
+	return result
+}
@@ -17,7 +17,11 @@ package main
 import (
 	"context"
 	"dagger/tests/internal/dagger"
+	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/dagger/dagger/util/parallel"
 )
@@ -84,6 +88,43 @@ func (t *Tests) SVG(ctx context.Context) error {
 	return nil
 }
 
+// +check
+// Test badge generation
+func (t *Tests) Badge(ctx context.Context) error {
+	return parallel.New().
+		WithJob("default", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests()
+			badge, err := results.Badge().Contents(ctx)
+			if err != nil {
+				return err
+			}
+			// yellowgreen for 71.43%
+			for _, expected := range []string{`aria-label="coverage: 71.4%"`, `fill="#a4a61d"`} {
+				if !strings.Contains(badge, expected) {
+					return fmt.Errorf("expected %s in:\n%s", expected, badge)
+				}
+			}
+			return nil
+		}).
+		WithJob("thresholds", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests()
+			badge, err := results.Badge(dagger.GoCoverageCoverageResultsBadgeOpts{
+				Label:      "unit tests",
+				Thresholds: []string{"70=#123456", "50=blue"},
+			}).Contents(ctx)
+			if err != nil {
+				return err
+			}
+			for _, expected := range []string{`aria-label="unit tests: 71.4%"`, `fill="#123456"`} {
+				if !strings.Contains(badge, expected) {
+					return fmt.Errorf("expected %s in:\n%s", expected, badge)
+				}
+			}
+			return nil
+		}).
+		Run(ctx)
+}
+
 // +check
 // Test Summary generation
 func (t *Tests) Summary(ctx context.Context) error {
@@ -107,26 +148,229 @@ func (t *Tests) Exec(ctx context.Context) error {
 	return results.Check(ctx, 19)
 }
 
-/*
+// +check
+// Test unit test options
+func (t *Tests) UnitTestsOptions(ctx context.Context) error {
+	return parallel.New().
+		WithJob("shards", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{Shards: 2})
+			return expectPercent(ctx, results, 71.43)
+		}).
+		WithJob("shards without packages", func(ctx context.Context) error {
+			_, err := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{
+				Packages: []string{"./cmd/nothing..."},
+				Shards:   2,
+			}).Sync(ctx)
+			if err == nil || !strings.Contains(err.Error(), "no packages match") {
+				return fmt.Errorf("expected no packages to match but saw: %v", err)
+			}
+			return nil
+		}).
+		WithJob("tags", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{Tags: []string{"triple"}})
+			return expectPercent(ctx, results, 85.71)
+		}).
+		WithJob("run and skip", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{
+				Tags: []string{"triple"},
+				Run:  "TestFoo|TestTripleIt",
+				Skip: "TestFoo",
+			})
+			// only TripleIt
+			return expectPercent(ctx, results, 14.29)
+		}).
+		WithJob("cover mode", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{
+				Packages:  []string{"./internal/..."},
+				CoverMode: dagger.GoCoverageCoverModeCount,
+				Args:      []string{"-count=2"},
+			})
+			contents, err := results.TextFormat().Contents(ctx)
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(contents, "mode: count\n") {
+				return fmt.Errorf("expected count mode coverage:\n%s", contents)
+			}
+			return expectPercent(ctx, results, 71.43)
+		}).
+		Run(ctx)
+}
+
+// +check
+// Test unit test results
+func (t *Tests) TestResults(ctx context.Context) error {
+	return parallel.New().
+		WithJob("passed", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{Tags: []string{"triple"}})
+			summary := results.TestSummary()
+			passed, err := summary.Passed(ctx)
+			if err != nil {
+				return err
+			}
+			failed, err := summary.Failed(ctx)
+			if err != nil {
+				return err
+			}
+			if passed != 2 || failed != 0 {
+				return fmt.Errorf("expected 2 passed and 0 failed tests but saw %d passed and %d failed", passed, failed)
+			}
+
+			junit, err := results.Junit().Contents(ctx)
+			if err != nil {
+				return err
+			}
+			for _, test := range []string{`name="TestFoo"`, `name="TestTripleIt"`} {
+				if !strings.Contains(junit, test) {
+					return fmt.Errorf("expected %s in:\n%s", test, junit)
+				}
+			}
+			return nil
+		}).
+		WithJob("failed", func(ctx context.Context) error {
+			_, err := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{Tags: []string{"fail"}}).Sync(ctx)
+			if err == nil || !strings.Contains(err.Error(), "TestFail") {
+				return fmt.Errorf("expected TestFail to fail but saw: %v", err)
+			}
+			return nil
+		}).
+		WithJob("allow failures", func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base()).UnitTests(dagger.GoCoverageUnitTestsOpts{
+				Tags:          []string{"fail"},
+				AllowFailures: true,
+			})
+			failures, err := results.TestSummary().Failures(ctx)
+			if err != nil {
+				return err
+			}
+			if len(failures) != 1 {
+				return fmt.Errorf("expected 1 failed test but saw %d", len(failures))
+			}
+			name, err := failures[0].Name(ctx)
+			if err != nil {
+				return err
+			}
+			if name != "TestFail" {
+				return fmt.Errorf("expected TestFail to fail but saw %s", name)
+			}
+
+			// coverage of the failed test is included
+			return expectPercent(ctx, results, 85.71)
+		}).
+		Run(ctx)
+}
+
+// +check
+// Test listing uncovered code
+func (t *Tests) Uncovered(ctx context.Context) error {
+	results := dag.GoCoverage(t.base()).UnitTests()
+
+	return parallel.New().
+		WithJob("blocks", func(ctx context.Context) error {
+			blocks, err := results.Uncovered(ctx)
+			if err != nil {
+				return err
+			}
+			var files []string
+			for _, b := range blocks {
+				file, err := b.File(ctx)
+				if err != nil {
+					return err
+				}
+				files = append(files, file)
+			}
+			if !slices.Equal(files, []string{"cmd/myapp/main.go", "internal/lib.gen.go"}) {
+				return fmt.Errorf("expected uncovered code in main.go and lib.gen.go but saw %v", files)
+			}
+			return nil
+		}).
+		WithJob("text", func(ctx context.Context) error {
+			text, err := results.UncoveredText().Contents(ctx)
+			if err != nil {
+				return err
+			}
+			for _, expected := range []string{"internal/lib.gen.go:10-12 (1 statement)", "   11 | \treturn 3 * x"} {
+				if !strings.Contains(text, expected) {
+					return fmt.Errorf("expected %q in:\n%s", expected, text)
+				}
+			}
+			return nil
+		}).
+		WithJob("json", func(ctx context.Context) error {
+			data, err := results.UncoveredJSON(dagger.GoCoverageCoverageResultsUncoveredJSONOpts{
+				Sort:  dagger.GoCoverageUncoveredOrderSize,
+				Limit: 1,
+			}).Contents(ctx)
+			if err != nil {
+				return err
+			}
+			var blocks []struct {
+				File       string `json:"file"`
+				Statements int    `json:"statements"`
+			}
+			if err := json.Unmarshal([]byte(data), &blocks); err != nil {
+				return err
+			}
+			if len(blocks) != 1 || blocks[0].Statements != 1 {
+				return fmt.Errorf("expected a single block with 1 statement:\n%s", data)
+			}
+			return nil
+		}).
+		Run(ctx)
+}
+
+// +check
 // Test merge
 func (t *Tests) Merge(ctx context.Context) error {
-	results1 := dag.Coverage(t.base(), opts).UnitTests()
-	results2 := dag.Coverage(t.base()).Exec("./cmd/myapp", []string{"argument"})
+	return parallel.New().
+		WithJob("text and binary", func(ctx context.Context) error {
+			results1 := dag.GoCoverage(t.base(), opts).UnitTests()
+			results2 := dag.GoCoverage(t.base(), opts).Exec("./cmd/myapp", []string{"argument"})
+
+			return expectPercent(ctx, results1.Merge(dagger.GoCoverageCoverageResultsMergeOpts{
+				Others: []*dagger.GoCoverageCoverageResults{results2},
+			}), 100)
+		}).
+		WithJob("binary", func(ctx context.Context) error {
+			results1 := dag.GoCoverage(t.base(), opts).Exec("./cmd/myapp", []string{"argument"})
+			results2 := dag.GoCoverage(t.base(), opts).Exec("./cmd/myapp", []string{"argument"})
+			profile := dag.GoCoverage(t.base(), opts).UnitTests().TextFormat()
 
-	results := results1.Merge(results2)
+			raw := t.base().
+				WithExec([]string{"go", "build", "-cover", "-o", "/app", "./cmd/myapp"}).
+				WithDirectory("/gocoverdir", dag.Directory()).
+				WithEnvVariable("GOCOVERDIR", "/gocoverdir").
+				WithExec([]string{"/app"}).
+				Directory("/gocoverdir")
 
+			results := results1.Merge(dagger.GoCoverageCoverageResultsMergeOpts{
+				Others: []*dagger.GoCoverageCoverageResults{results2},
+				Raw:    []*dagger.Directory{raw},
+			})
+			if err := expectPercent(ctx, results, 100); err != nil {
+				return fmt.Errorf("binary: %w", err)
+			}
+
+			results = results1.Merge(dagger.GoCoverageCoverageResultsMergeOpts{
+				Others:   []*dagger.GoCoverageCoverageResults{results2},
+				Profiles: []*dagger.File{profile},
+			})
+			return expectPercent(ctx, results, 100)
+		}).
+		Run(ctx)
+}
+
+func expectPercent(ctx context.Context, results *dagger.GoCoverageCoverageResults, expected float64) error {
 	cov, err := results.Percent(ctx)
 	if err != nil {
 		return err
 	}
-
-	expected := 100.0
 	if math.Abs(cov-expected) > 0.01 {
 		return fmt.Errorf("expected %0.2f%% code coverage but saw %0.2f%%", expected, cov)
 	}
 	return nil
 }
-*/
+
 // +check
 func (t *Tests) Directory(ctx context.Context) error {
 	return parallel.New().
@@ -158,3 +402,301 @@ func (t *Tests) Directory(ctx context.Context) error {
 		}).
 		Run(ctx)
 }
+
+// +check
+// Test per-package statement coverage
+func (t *Tests) Packages(ctx context.Context) error {
+	results := dag.GoCoverage(t.base()).UnitTests()
+	pkgs, err := results.Packages(ctx)
+	if err != nil {
+		return err
+	}
+
+	const pkg = "example.com/hello-world/internal"
+	for _, pc := range pkgs {
+		name, err := pc.Package(ctx)
+		if err != nil {
+			return err
+		}
+		if name != pkg {
+			continue
+		}
+
+		cov, err := pc.Percent(ctx)
+		if err != nil {
+			return err
+		}
+		expected := 100 * 5 / 6.0
+		if math.Abs(cov-expected) > 0.01 {
+			return fmt.Errorf("expected %0.2f%% code coverage of %s but saw %0.2f%%", expected, pkg, cov)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("expected coverage of %s in %d packages", pkg, len(pkgs))
+}
+
+// +check
+// Test per-package coverage check
+func (t *Tests) CheckPackages(ctx context.Context) error {
+	results := dag.GoCoverage(t.base()).UnitTests()
+	return parallel.New().
+		WithJob("sufficient", func(ctx context.Context) error {
+			return results.Check(ctx, 0, dagger.GoCoverageCoverageResultsCheckOpts{
+				Packages: []string{"**/internal=80"},
+			})
+		}).
+		WithJob("insufficient", func(ctx context.Context) error {
+			err := results.Check(ctx, 0, dagger.GoCoverageCoverageResultsCheckOpts{
+				Packages: []string{"example.com/hello-world/**=80"},
+			})
+			if err == nil {
+				return fmt.Errorf("expected the coverage of example.com/hello-world/cmd/myapp to be insufficient")
+			}
+			return nil
+		}).
+		WithJob("insufficient total and packages", func(ctx context.Context) error {
+			err := results.Check(ctx, 100, dagger.GoCoverageCoverageResultsCheckOpts{
+				Packages: []string{"example.com/hello-world/**=80"},
+			})
+			if err == nil || !strings.Contains(err.Error(), "need at least 100.00%") || !strings.Contains(err.Error(), "example.com/hello-world/cmd/myapp") {
+				return fmt.Errorf("expected the total and the package coverage to be insufficient but saw: %v", err)
+			}
+			return nil
+		}).
+		Run(ctx)
+}
+
+// +check
+// Test Cobertura, LCOV and SonarQube reports
+func (t *Tests) Reports(ctx context.Context) error {
+	results := dag.GoCoverage(t.base(), opts).UnitTests()
+
+	contains := func(name string, report *dagger.File, expected, excluded string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			contents, err := report.Contents(ctx)
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(contents, expected) {
+				return fmt.Errorf("expected %s report to contain %q:\n%s", name, expected, contents)
+			}
+			if strings.Contains(contents, excluded) {
+				return fmt.Errorf("expected %s report to exclude %q:\n%s", name, excluded, contents)
+			}
+			return nil
+		}
+	}
+
+	return parallel.New().
+		WithJob("cobertura", contains("cobertura", results.Cobertura(), `filename="internal/lib.go"`, "lib.gen.go")).
+		WithJob("lcov", contains("lcov", results.Lcov(), "SF:internal/lib.go\n", "lib.gen.go")).
+		WithJob("sonar", contains("sonar", results.SonarGeneric(), `<file path="internal/lib.go">`, "lib.gen.go")).
+		Run(ctx)
+}
+
+// +check
+// Test coverage of the lines changed since a base revision
+func (t *Tests) DiffCoverage(ctx context.Context) error {
+	src := dag.CurrentModule().Source().Directory("testdata/hello-world-go")
+	base := dag.Container().
+		From("alpine/git").
+		WithDirectory("/repo", src).
+		WithWorkdir("/repo").
+		WithExec([]string{"git", "init"}).
+		WithExec([]string{"git", "config", "user.name", "test"}).
+		WithExec([]string{"git", "config", "user.email", "test@dagger.io"}).
+		WithExec([]string{"git", "add", "."}).
+		WithExec([]string{"git", "commit", "-m", "base"}).
+		Directory("/repo").AsGit().Head()
+
+	// add an untested function
+	lib, err := src.File("internal/lib.go").Contents(ctx)
+	if err != nil {
+		return err
+	}
+	changed := src.WithNewFile("internal/lib.go", lib+"\nfunc QuadrupleIt(x int) int {\n\treturn 4 * x\n}\n")
+	ctr := dag.Go().WithSource(changed).WithCgoDisabled().Container()
+
+	diff := dag.GoCoverage(ctr).UnitTests().DiffCoverage(base)
+	uncovered, err := diff.Uncovered(ctx)
+	if err != nil {
+		return err
+	}
+	// QuadrupleIt is on lines 10-12, the block starts at the function like in the Uncovered test
+	expected := []string{"internal/lib.go:10-12"}
+	if !slices.Equal(uncovered, expected) {
+		return fmt.Errorf("expected uncovered lines %v but saw %v", expected, uncovered)
+	}
+
+	if err := diff.Check(ctx, 50); err == nil {
+		return fmt.Errorf("expected the coverage of the changed lines to be insufficient")
+	}
+	return nil
+}
+
+// +check
+// Test coverage of a service
+func (t *Tests) Service(ctx context.Context) error {
+	src := dag.CurrentModule().Source().Directory("testdata/hello-world-server")
+	base := dag.Go().WithSource(src).WithCgoDisabled().Container()
+
+	cs := dag.GoCoverage(base).Service(".", dagger.GoCoverageServiceOpts{Ports: []int{8080}})
+	svc, err := cs.Service().Start(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := dag.Container().
+		From("alpine").
+		WithServiceBinding("server", svc).
+		WithExec([]string{"wget", "-qO-", "http://server:8080/double?x=2"}).
+		Stdout(ctx)
+	if err != nil {
+		return err
+	}
+	if out != "4\n" {
+		return fmt.Errorf("unexpected response from the service: %q", out)
+	}
+
+	results := cs.Results()
+	if err := results.Check(ctx, 70); err != nil {
+		return err
+	}
+
+	// merging the same results does not change the coverage in set mode
+	return results.Merge(dagger.GoCoverageCoverageResultsMergeOpts{
+		Others: []*dagger.GoCoverageCoverageResults{results},
+	}).Check(ctx, 70)
+}
+
+// +check
+// Test excluding files with globs and generated files
+func (t *Tests) Excludes(ctx context.Context) error {
+	noGen := func(opts dagger.GoCoverageOpts) func(context.Context) error {
+		return func(ctx context.Context) error {
+			results := dag.GoCoverage(t.base(), opts).UnitTests()
+			matches, err := results.Summary().Search(ctx, "gen")
+			if err != nil {
+				return err
+			}
+			if len(matches) != 0 {
+				return fmt.Errorf("expected no matches but found %d", len(matches))
+			}
+			return nil
+		}
+	}
+
+	return parallel.New().
+		WithJob("globs", noGen(dagger.GoCoverageOpts{ExcludeGlobs: []string{"internal/*.gen.go"}})).
+		WithJob("generated", noGen(dagger.GoCoverageOpts{ExcludeGenerated: true})).
+		Run(ctx)
+}
+
+// +check
+// Test comparing coverage with a baseline
+func (t *Tests) Compare(ctx context.Context) error {
+	unit := dag.GoCoverage(t.base()).UnitTests()
+	exec := dag.GoCoverage(t.base()).Exec("./cmd/myapp", []string{"argument"})
+
+	return parallel.New().
+		WithJob("regression", func(ctx context.Context) error {
+			comparison := unit.Compare(dagger.GoCoverageCoverageResultsCompareOpts{Baseline: exec})
+			lost, err := comparison.LostCoverage(ctx)
+			if err != nil {
+				return err
+			}
+			var names []string
+			for _, fn := range lost {
+				name, err := fn.Name(ctx)
+				if err != nil {
+					return err
+				}
+				names = append(names, name)
+			}
+			if !slices.Contains(names, "example.com/hello-world/cmd/myapp/main.go:main") {
+				return fmt.Errorf("expected main to lose coverage, lost coverage: %v", names)
+			}
+			if err := comparison.CheckNoRegression(ctx); err == nil {
+				return fmt.Errorf("expected a coverage regression")
+			}
+			return nil
+		}).
+		WithJob("baseline profile", func(ctx context.Context) error {
+			comparison := unit.Compare(dagger.GoCoverageCoverageResultsCompareOpts{BaselineProfile: unit.TextFormat()})
+			change, err := comparison.Total().Change(ctx)
+			if err != nil {
+				return err
+			}
+			if change != 0 {
+				return fmt.Errorf("expected no change in coverage but saw %0.2f", change)
+			}
+			return comparison.CheckNoRegression(ctx)
+		}).
+		WithJob("baseline profile with a deleted file", func(ctx context.Context) error {
+			text, err := unit.TextFormat().Contents(ctx)
+			if err != nil {
+				return err
+			}
+			profile := dag.Directory().
+				WithNewFile("coverage.txt", text+"example.com/hello-world/internal/deleted.go:3.21,5.2 1 1\n").
+				File("coverage.txt")
+			packages, err := unit.Compare(dagger.GoCoverageCoverageResultsCompareOpts{BaselineProfile: profile}).Packages(ctx)
+			if err != nil {
+				return err
+			}
+			for _, pkg := range packages {
+				name, err := pkg.Name(ctx)
+				if err != nil {
+					return err
+				}
+				before, err := pkg.Before(ctx)
+				if err != nil {
+					return err
+				}
+				after, err := pkg.After(ctx)
+				if err != nil {
+					return err
+				}
+				if name == "example.com/hello-world/internal" && before > after {
+					return nil
+				}
+			}
+			return fmt.Errorf("expected the coverage of the package of the deleted file to drop")
+		}).
+		Run(ctx)
+}
+
+// +check
+// Test markdown report
+func (t *Tests) Markdown(ctx context.Context) error {
+	unit := dag.GoCoverage(t.base()).UnitTests()
+	exec := dag.GoCoverage(t.base()).Exec("./cmd/myapp", []string{"argument"})
+
+	contains := func(report *dagger.File, expected ...string) func(context.Context) error {
+		return func(ctx context.Context) error {
+			md, err := report.Contents(ctx)
+			if err != nil {
+				return err
+			}
+			for _, e := range expected {
+				if !strings.Contains(md, e) {
+					return fmt.Errorf("expected markdown report to contain %q:\n%s", e, md)
+				}
+			}
+			return nil
+		}
+	}
+
+	return parallel.New().
+		WithJob("report", contains(unit.Markdown(),
+			"## Code coverage: 71.43%",
+			"| `example.com/hello-world/internal` | 6 | 83.33% |",
+			"`example.com/hello-world/internal.TripleIt`",
+		)).
+		WithJob("baseline", contains(unit.Markdown(dagger.GoCoverageCoverageResultsMarkdownOpts{Baseline: exec}),
+			"| Package | Statements | Coverage | Change |",
+			"function(s) lost coverage",
+		)).
+		Run(ctx)
+}
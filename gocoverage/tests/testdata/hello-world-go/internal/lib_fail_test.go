@@ -0,0 +1,13 @@
+//go:build fail
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestFail(t *testing.T) {
+	if TripleIt(2) != 7 {
+		t.Error("expected a failure")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build triple
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestTripleIt(t *testing.T) {
+	if TripleIt(2) != 6 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,3 @@
+module example.com/hello-world-server
+
+go 1.25.0
@@ -0,0 +1,40 @@
+// An example server that can be run as a service to test coverage of integration tests.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+func double(w http.ResponseWriter, r *http.Request) {
+	x, err := strconv.Atoi(r.URL.Query().Get("x"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprintln(w, 2*x)
+}
+
+func main() {
+	// exit normally when stopped so the coverage is written
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	http.HandleFunc("/double", double)
+	srv := &http.Server{Addr: ":8080"}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
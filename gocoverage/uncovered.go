@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"dagger/coverage/internal/dagger"
+	"dagger/coverage/util"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Consecutive lines of code that were not executed
+type UncoveredBlock struct {
+	// Import path of the package
+	Package string
+	// File path relative to the module root
+	File string
+	// First line of the block
+	StartLine int
+	// Last line of the block
+	EndLine int
+	// Number of statements in the block
+	Statements int
+	// Source lines of the block
+	Source string
+}
+
+// Order of uncovered blocks
+type UncoveredOrder string
+
+const (
+	// By package, file and line
+	UncoveredOrderPackage UncoveredOrder = "PACKAGE"
+	// Most statements first
+	UncoveredOrderSize UncoveredOrder = "SIZE"
+)
+
+// Uncovered code with its source lines, e.g. to plan which tests to write
+func (cr *CoverageResults) Uncovered(ctx context.Context,
+	// order of the blocks
+	// +optional
+	// +default="PACKAGE"
+	sort UncoveredOrder,
+
+	// maximum number of blocks, 0 for all
+	// +optional
+	limit int,
+) ([]*UncoveredBlock, error) {
+	blocks, err := cr.uncovered(ctx, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*UncoveredBlock, 0, len(blocks))
+	for _, b := range blocks {
+		result = append(result, &UncoveredBlock{
+			Package:    b.Package,
+			File:       b.File,
+			StartLine:  b.StartLine,
+			EndLine:    b.EndLine,
+			Statements: b.Statements,
+			Source:     b.Source,
+		})
+	}
+	return result, nil
+}
+
+// Uncovered code with its source lines, as text
+func (cr *CoverageResults) UncoveredText(ctx context.Context,
+	// order of the blocks
+	// +optional
+	// +default="PACKAGE"
+	sort UncoveredOrder,
+
+	// maximum number of blocks, 0 for all
+	// +optional
+	limit int,
+) (*dagger.File, error) {
+	blocks, err := cr.uncovered(ctx, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return dag.Directory().WithNewFile("uncovered.txt", util.FormatUncovered(blocks)).File("uncovered.txt"), nil
+}
+
+// Uncovered code with its source lines, as a JSON array of objects with package, file, startLine, endLine,
+// statements and source
+func (cr *CoverageResults) UncoveredJSON(ctx context.Context,
+	// order of the blocks
+	// +optional
+	// +default="PACKAGE"
+	sort UncoveredOrder,
+
+	// maximum number of blocks, 0 for all
+	// +optional
+	limit int,
+) (*dagger.File, error) {
+	blocks, err := cr.uncovered(ctx, sort, limit)
+	if err != nil {
+		return nil, err
+	}
+	if blocks == nil {
+		blocks = []util.UncoveredBlock{}
+	}
+
+	data, err := json.MarshalIndent(blocks, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return dag.Directory().WithNewFile("uncovered.json", string(data)+"\n").File("uncovered.json"), nil
+}
+
+// uncovered finds the uncovered blocks in the source of the base container
+func (cr *CoverageResults) uncovered(ctx context.Context, sort UncoveredOrder, limit int) ([]util.UncoveredBlock, error) {
+	profiles, err := cr.profiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	modulePath, err := cr.Coverage.modulePath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	src := cr.Coverage.Base.Directory(".")
+	var blocks []util.UncoveredBlock
+	for _, p := range profiles {
+		if total, covered := p.Statements(); total == covered {
+			// skip reading fully covered files
+			continue
+		}
+
+		file := util.TrimModule(p.FileName, modulePath)
+		contents, err := src.File(file).Contents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", p.FileName, err)
+		}
+		blocks = append(blocks, util.Uncovered(p, file, contents)...)
+	}
+
+	util.SortUncovered(blocks, strings.ToLower(string(sort)))
+	if limit > 0 && len(blocks) > limit {
+		blocks = blocks[:limit]
+	}
+	return blocks, nil
+}
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BadgeThreshold is the color of a badge for a percentage of at least Percent.
+type BadgeThreshold struct {
+	Percent float64
+	// Color is a hex color, e.g. "#4c1".
+	Color string
+}
+
+// BadgeColors are the named colors of shields.io badges.
+var BadgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellowgreen": "#a4a61d",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+	"grey":        "#555",
+}
+
+// DefaultBadgeThresholds color a badge from red below 50% to bright green from 90%.
+var DefaultBadgeThresholds = []string{"90=brightgreen", "80=green", "70=yellowgreen", "60=yellow", "50=orange", "0=red"}
+
+var hexColorRegex = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// ParseBadgeThresholds parses thresholds in the form "<percent>=<color>", where color is a shields.io color
+// name or a hex color, e.g. "80=green" or "80=#97ca00".  The result is sorted by descending percent.
+func ParseBadgeThresholds(specs []string) ([]BadgeThreshold, error) {
+	thresholds := make([]BadgeThreshold, 0, len(specs))
+	for _, spec := range specs {
+		pct, color, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid badge threshold %q: expected <percent>=<color>", spec)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(pct, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid badge threshold %q: percent is not a number: %w", spec, err)
+		}
+		if named, ok := BadgeColors[color]; ok {
+			color = named
+		} else if !hexColorRegex.MatchString(color) {
+			return nil, fmt.Errorf("invalid badge threshold %q: expected a color name or #rgb or #rrggbb", spec)
+		}
+		thresholds = append(thresholds, BadgeThreshold{Percent: percent, Color: color})
+	}
+	slices.SortStableFunc(thresholds, func(a, b BadgeThreshold) int { return cmp.Compare(b.Percent, a.Percent) })
+	return thresholds, nil
+}
+
+// BadgeColor is the color of the highest threshold reached by percent, red if none is reached.
+// Thresholds must be sorted by descending percent.
+func BadgeColor(percent float64, thresholds []BadgeThreshold) string {
+	for _, t := range thresholds {
+		if percent >= t.Percent {
+			return t.Color
+		}
+	}
+	return BadgeColors["red"]
+}
+
+// Badge renders a flat shields.io style badge, e.g. "coverage | 71.4%".
+func Badge(label string, percent float64, color string) string {
+	value := strconv.FormatFloat(math.Round(percent*10)/10, 'f', -1, 64) + "%"
+
+	labelText := textWidth(label)
+	valueText := textWidth(value)
+	labelWidth := int(math.Round(labelText)) + 10
+	valueWidth := int(math.Round(valueText)) + 10
+	width := labelWidth + valueWidth
+
+	title := html.EscapeString(label + ": " + value)
+	label = html.EscapeString(label)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`, width, title)
+	fmt.Fprintf(&sb, `<title>%s</title>`, title)
+	sb.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&sb, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, width)
+	fmt.Fprintf(&sb, `<g clip-path="url(#r)"><rect width="%d" height="20" fill="#555"/><rect x="%d" width="%d" height="20" fill="%s"/><rect width="%d" height="20" fill="url(#s)"/></g>`,
+		labelWidth, labelWidth, valueWidth, color, width)
+	sb.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" text-rendering="geometricPrecision" font-size="110">`)
+	for _, text := range []struct {
+		x      int
+		length int
+		text   string
+	}{
+		{x: labelWidth * 5, length: int(math.Round(labelText * 10)), text: label},
+		{x: labelWidth*10 + valueWidth*5, length: int(math.Round(valueText * 10)), text: value},
+	} {
+		// text with a shadow, scaled down for sub-pixel positioning
+		fmt.Fprintf(&sb, `<text aria-hidden="true" x="%d" y="150" fill="#010101" fill-opacity=".3" transform="scale(.1)" textLength="%d">%s</text>`,
+			text.x, text.length, text.text)
+		fmt.Fprintf(&sb, `<text x="%d" y="140" transform="scale(.1)" fill="#fff" textLength="%d">%s</text>`,
+			text.x, text.length, text.text)
+	}
+	sb.WriteString("</g></svg>\n")
+	return sb.String()
+}
+
+// verdanaWidths are the widths in pixels of characters in 11px Verdana, the font of shields.io badges.
+var verdanaWidths = map[rune]float64{
+	' ': 3.87, '!': 4.33, '"': 5.05, '#': 9.0, '$': 6.99, '%': 11.84, '&': 7.99, '\'': 2.95,
+	'(': 4.99, ')': 4.99, '*': 6.99, '+': 9.0, ',': 4.0, '-': 4.99, '.': 4.0, '/': 4.99,
+	'0': 6.99, '1': 6.99, '2': 6.99, '3': 6.99, '4': 6.99, '5': 6.99, '6': 6.99, '7': 6.99, '8': 6.99, '9': 6.99,
+	':': 4.99, ';': 4.99, '<': 9.0, '=': 9.0, '>': 9.0, '?': 5.99, '@': 11.0,
+	'A': 7.52, 'B': 7.54, 'C': 7.68, 'D': 8.48, 'E': 6.96, 'F': 6.32, 'G': 8.53, 'H': 8.27, 'I': 4.61,
+	'J': 5.0, 'K': 7.62, 'L': 6.12, 'M': 9.27, 'N': 8.23, 'O': 8.66, 'P': 6.63, 'Q': 8.66, 'R': 7.65,
+	'S': 7.52, 'T': 6.78, 'U': 8.05, 'V': 7.52, 'W': 10.88, 'X': 7.54, 'Y': 6.77, 'Z': 7.54,
+	'[': 4.99, '\\': 4.99, ']': 4.99, '^': 9.0, '_': 6.99, '`': 6.99,
+	'a': 6.61, 'b': 6.85, 'c': 5.73, 'd': 6.85, 'e': 6.55, 'f': 3.87, 'g': 6.85, 'h': 6.96, 'i': 3.02,
+	'j': 3.79, 'k': 6.51, 'l': 3.02, 'm': 10.69, 'n': 6.96, 'o': 6.68, 'p': 6.85, 'q': 6.85, 'r': 4.69,
+	's': 5.73, 't': 4.33, 'u': 6.96, 'v': 6.51, 'w': 8.99, 'x': 6.51, 'y': 6.51, 'z': 5.78,
+	'{': 6.98, '|': 4.99, '}': 6.98, '~': 9.0,
+}
+
+// textWidth estimates the width in pixels of text in 11px Verdana.
+func textWidth(text string) float64 {
+	width := 0.0
+	for _, r := range text {
+		w, ok := verdanaWidths[r]
+		if !ok {
+			// wide enough for most other characters
+			w = verdanaWidths['m']
+		}
+		width += w
+	}
+	return width
+}
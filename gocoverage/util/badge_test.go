@@ -0,0 +1,49 @@
+package util
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseBadgeThresholds(t *testing.T) {
+	got, err := ParseBadgeThresholds([]string{"50%=orange", "80=#97CA00", "0=#e05d44"})
+	require.NoError(t, err)
+	assert.Equal(t, []BadgeThreshold{
+		{Percent: 80, Color: "#97CA00"},
+		{Percent: 50, Color: "#fe7d37"},
+		{Percent: 0, Color: "#e05d44"},
+	}, got)
+
+	for _, spec := range []string{"80", "high=green", "80=greenish", "80=#12345", "80=97ca00"} {
+		_, err := ParseBadgeThresholds([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func Test_BadgeColor(t *testing.T) {
+	thresholds, err := ParseBadgeThresholds(DefaultBadgeThresholds)
+	require.NoError(t, err)
+
+	assert.Equal(t, "#4c1", BadgeColor(100, thresholds))
+	assert.Equal(t, "#4c1", BadgeColor(90, thresholds))
+	assert.Equal(t, "#a4a61d", BadgeColor(71.43, thresholds))
+	assert.Equal(t, "#e05d44", BadgeColor(12, thresholds))
+	assert.Equal(t, "#e05d44", BadgeColor(12, nil))
+}
+
+func Test_Badge(t *testing.T) {
+	svg := Badge("coverage", 71.428, "#a4a61d")
+
+	assert.NoError(t, xml.Unmarshal([]byte(svg), new(struct{})))
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="107" height="20" role="img" aria-label="coverage: 71.4%">`), svg)
+	assert.Contains(t, svg, `<rect width="60" height="20" fill="#555"/><rect x="60" width="47" height="20" fill="#a4a61d"/>`)
+	assert.Contains(t, svg, `<text x="300" y="140" transform="scale(.1)" fill="#fff" textLength="502">coverage</text>`)
+	assert.Contains(t, svg, `<text x="835" y="140" transform="scale(.1)" fill="#fff" textLength="368">71.4%</text>`)
+
+	assert.Contains(t, Badge("a<b", 100, "#4c1"), `>a&lt;b</text>`)
+	assert.Contains(t, Badge("coverage", 99.96, "#4c1"), `>100%</text>`)
+}
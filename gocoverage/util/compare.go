@@ -0,0 +1,98 @@
+package util
+
+import (
+	"cmp"
+	"slices"
+)
+
+// Delta is the change in coverage of a package, function or the total between a baseline and the current coverage.
+type Delta struct {
+	// Name of the package, or ID of the function, empty for the total.
+	Name string
+	// Before is the baseline percentage, 0 if Added.
+	Before float64
+	// After is the current percentage, 0 if Removed.
+	After float64
+	// Added is set if the package or function is not in the baseline.
+	Added bool
+	// Removed is set if the package or function is only in the baseline.
+	Removed bool
+}
+
+// Change in percentage points.
+func (d Delta) Change() float64 {
+	return d.After - d.Before
+}
+
+// Regressed reports whether coverage dropped by more than tolerance percentage points.
+// Added and removed packages or functions do not regress.
+func (d Delta) Regressed(tolerance float64) bool {
+	return !d.Added && !d.Removed && d.Change() < -tolerance
+}
+
+// Comparison of the current coverage with a baseline.
+type Comparison struct {
+	Total     Delta
+	Packages  []Delta
+	Functions []Delta
+}
+
+// Compare computes the total, per-package and per-function coverage deltas, sorted by name.
+func Compare(baseline, current []*Profile, baselineFuncs, currentFuncs []FuncCoverage) Comparison {
+	total := func(profiles []*Profile) float64 {
+		var pc PackageCoverage
+		for _, p := range profiles {
+			total, covered := p.Statements()
+			pc.Statements += total
+			pc.Covered += covered
+		}
+		return pc.Percent()
+	}
+	percents := func(pkgs []PackageCoverage) map[string]float64 {
+		m := make(map[string]float64, len(pkgs))
+		for _, pc := range pkgs {
+			m[pc.Package] = pc.Percent()
+		}
+		return m
+	}
+	funcPercents := func(funcs []FuncCoverage) map[string]float64 {
+		m := make(map[string]float64, len(funcs))
+		for _, fc := range funcs {
+			m[fc.ID] = fc.Percent()
+		}
+		return m
+	}
+
+	return Comparison{
+		Total:     Delta{Before: total(baseline), After: total(current)},
+		Packages:  deltas(percents(Packages(baseline)), percents(Packages(current))),
+		Functions: deltas(funcPercents(baselineFuncs), funcPercents(currentFuncs)),
+	}
+}
+
+func deltas(before, after map[string]float64) []Delta {
+	var result []Delta
+	for name, b := range before {
+		a, ok := after[name]
+		result = append(result, Delta{Name: name, Before: b, After: a, Removed: !ok})
+	}
+	for name, a := range after {
+		if _, ok := before[name]; !ok {
+			result = append(result, Delta{Name: name, After: a, Added: true})
+		}
+	}
+	slices.SortFunc(result, func(a, b Delta) int { return cmp.Compare(a.Name, b.Name) })
+	return result
+}
+
+// Regressions returns the deltas that dropped by more than tolerance percentage points, largest drop first.
+func Regressions(deltas []Delta, tolerance float64) []Delta {
+	var result []Delta
+	for _, d := range deltas {
+		if d.Regressed(tolerance) {
+			result = append(result, d)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b Delta) int { return cmp.Compare(a.Change(), b.Change()) })
+	return result
+}
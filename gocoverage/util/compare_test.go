@@ -0,0 +1,66 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Compare(t *testing.T) {
+	baseline, err := ParseProfiles(`mode: set
+example.com/app/a/a.go:3.10,5.2 2 1
+example.com/app/a/a.go:7.10,9.2 2 1
+example.com/app/old/old.go:3.10,5.2 1 1
+`)
+	require.NoError(t, err)
+	current, err := ParseProfiles(`mode: set
+example.com/app/a/a.go:3.10,5.2 2 1
+example.com/app/a/a.go:7.10,9.2 2 0
+example.com/app/b/b.go:3.10,5.2 1 1
+`)
+	require.NoError(t, err)
+
+	baselineFuncs := []FuncCoverage{
+		{ID: "example.com/app/a/a.go:A", Statements: 2, Covered: 2},
+		{ID: "example.com/app/a/a.go:B", Statements: 2, Covered: 2},
+		{ID: "example.com/app/old/old.go:Old", Statements: 1, Covered: 1},
+	}
+	currentFuncs := []FuncCoverage{
+		{ID: "example.com/app/a/a.go:A", Statements: 2, Covered: 2},
+		{ID: "example.com/app/a/a.go:B", Statements: 2, Covered: 0},
+		{ID: "example.com/app/b/b.go:New", Statements: 1, Covered: 1},
+	}
+
+	got := Compare(baseline, current, baselineFuncs, currentFuncs)
+	assert.Equal(t, Comparison{
+		Total: Delta{Before: 100, After: 60},
+		Packages: []Delta{
+			{Name: "example.com/app/a", Before: 100, After: 50},
+			{Name: "example.com/app/b", After: 100, Added: true},
+			{Name: "example.com/app/old", Before: 100, Removed: true},
+		},
+		Functions: []Delta{
+			{Name: "example.com/app/a/a.go:A", Before: 100, After: 100},
+			{Name: "example.com/app/a/a.go:B", Before: 100, After: 0},
+			{Name: "example.com/app/b/b.go:New", After: 100, Added: true},
+			{Name: "example.com/app/old/old.go:Old", Before: 100, Removed: true},
+		},
+	}, got)
+
+	assert.Equal(t, -40.0, got.Total.Change())
+	assert.True(t, got.Total.Regressed(39.9))
+	assert.False(t, got.Total.Regressed(40))
+	assert.Equal(t, []Delta{{Name: "example.com/app/a/a.go:B", Before: 100, After: 0}}, Regressions(got.Functions, 0))
+}
+
+func Test_Regressions(t *testing.T) {
+	deltas := []Delta{
+		{Name: "a", Before: 80, After: 79.5},
+		{Name: "b", Before: 80, After: 50},
+		{Name: "c", Before: 80, After: 70},
+		{Name: "d", Before: 80, After: 90},
+	}
+	assert.Equal(t, []Delta{deltas[1], deltas[2]}, Regressions(deltas, 1))
+	assert.Equal(t, []Delta{deltas[1], deltas[2], deltas[0]}, Regressions(deltas, 0))
+}
@@ -0,0 +1,176 @@
+package util
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ChangedLines returns the line numbers, starting at 1, of the lines in new that are added or
+// modified compared to old, using a minimal line diff (Myers' algorithm). If the files differ by more than
+// maxEdits lines, all lines between the common prefix and suffix are returned.
+func ChangedLines(old, new string) []int {
+	a, b := splitLines(old), splitLines(new)
+
+	// lines in the common prefix and suffix are unchanged
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var changed []int
+	for _, i := range insertions(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		changed = append(changed, prefix+i+1)
+	}
+	return changed
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// maxEdits bounds the edit distance searched for by insertions, beyond which all lines are considered changed.
+// The trace of Myers' algorithm takes O(maxEdits²) memory.
+const maxEdits = 1000
+
+// insertions returns the indexes of the lines of b that are not part of a shortest edit script from a to b.
+// If the edit script is longer than maxEdits, all lines of b are returned.
+func insertions(a, b []string) []int {
+	n, m := len(a), len(b)
+	if m == 0 {
+		return nil
+	}
+
+	// v[k+offset] is the furthest x reached on diagonal k, trace keeps diagonals -d-1 to d+1 of v for each
+	// edit distance d, the only ones read when backtracking
+	offset := n + m + 1
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+	for d := 0; d <= min(n+m, maxEdits); d++ {
+		trace = append(trace, slices.Clone(v[offset-d-1:offset+d+2]))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset] // insertion
+			} else {
+				x = v[k-1+offset] + 1 // deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m, d)
+			}
+		}
+	}
+
+	all := make([]int, m)
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// backtrack walks the trace of Myers' algorithm from the end, collecting the inserted lines of b.
+func backtrack(trace [][]int, x, y, d int) []int {
+	var inserted []int
+	for ; d > 0; d-- {
+		// v[k+d+1] is the furthest x reached on diagonal k before edit d
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d+1] < v[k+1+d+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d+1]
+		prevY := prevX - prevK
+		// skip the diagonal of equal lines
+		for x > prevX && y > prevY {
+			x--
+			y--
+		}
+		if prevK == k+1 {
+			inserted = append(inserted, prevY)
+		}
+		x, y = prevX, prevY
+	}
+	slices.Reverse(inserted)
+	return inserted
+}
+
+// DiffResult is the coverage of the changed lines with statements.
+type DiffResult struct {
+	// Lines is the number of changed lines with statements.
+	Lines int
+	// Covered is the number of changed lines with statements executed at least once.
+	Covered int
+	// Uncovered are ranges of uncovered changed lines, e.g. "internal/foo.go:12-14".
+	Uncovered []string
+}
+
+// Percent of changed lines covered, 100 if no changed line has statements.
+func (dr DiffResult) Percent() float64 {
+	if dr.Lines == 0 {
+		return 100
+	}
+	return 100 * float64(dr.Covered) / float64(dr.Lines)
+}
+
+// DiffCoverage computes the coverage of changed lines, keyed by file path relative to the module root.
+func DiffCoverage(profiles []*Profile, modulePath string, changed map[string][]int) DiffResult {
+	var result DiffResult
+	for _, p := range profiles {
+		file := TrimModule(p.FileName, modulePath)
+		lines := changed[file]
+		if len(lines) == 0 {
+			continue
+		}
+
+		var uncovered []int
+		for _, l := range p.Lines() {
+			if _, ok := slices.BinarySearch(lines, l.Line); !ok {
+				continue
+			}
+			result.Lines++
+			if l.Count > 0 {
+				result.Covered++
+			} else {
+				uncovered = append(uncovered, l.Line)
+			}
+		}
+		for _, r := range lineRanges(uncovered) {
+			result.Uncovered = append(result.Uncovered, file+":"+r)
+		}
+	}
+	return result
+}
+
+// lineRanges merges sorted line numbers into ranges of consecutive lines, e.g. "3" and "5-7".
+func lineRanges(lines []int) []string {
+	var ranges []string
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, fmt.Sprint(lines[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return ranges
+}
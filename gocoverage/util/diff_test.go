@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ChangedLines(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []int
+	}{
+		{name: "Unchanged",
+			old:  "a\nb\nc\n",
+			new:  "a\nb\nc\n",
+			want: nil,
+		},
+		{name: "New File",
+			old:  "",
+			new:  "a\nb\n",
+			want: []int{1, 2},
+		},
+		{name: "Deleted Lines",
+			old:  "a\nb\nc\n",
+			new:  "a\nc\n",
+			want: nil,
+		},
+		{name: "Inserted Lines",
+			old:  "a\nb\nc\n",
+			new:  "a\nx\nb\ny\nz\nc\n",
+			want: []int{2, 4, 5},
+		},
+		{name: "Modified Line",
+			old:  "a\nb\nc\n",
+			new:  "a\nB\nc\n",
+			want: []int{2},
+		},
+		{name: "Moved Line",
+			old:  "a\nb\nc\nd\n",
+			new:  "b\nc\nd\na\n",
+			want: []int{4},
+		},
+		{name: "Repeated Lines",
+			old:  "}\n}\nx\n}\n",
+			new:  "}\ny\n}\n}\nx\n}\n",
+			want: []int{2, 3},
+		},
+		{name: "No Trailing Newline",
+			old:  "a\nb",
+			new:  "a\nb\nc",
+			want: []int{3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ChangedLines(tt.old, tt.new))
+		})
+	}
+}
+
+func Test_ChangedLinesMaxEdits(t *testing.T) {
+	lines := func(modified int) string {
+		var sb strings.Builder
+		for i := range 2000 {
+			if i%2 == 0 && i < 2*modified {
+				fmt.Fprintf(&sb, "modified %d\n", i)
+			} else {
+				fmt.Fprintf(&sb, "line %d\n", i)
+			}
+		}
+		return sb.String()
+	}
+
+	// each modified line is a deletion and an insertion
+	assert.Len(t, ChangedLines(lines(0), lines(maxEdits/2)), maxEdits/2)
+
+	// too many edits, all lines between the common prefix and suffix are changed
+	changed := ChangedLines(lines(0), lines(maxEdits/2+1))
+	require.Len(t, changed, maxEdits+1)
+	assert.Equal(t, 1, changed[0])
+	assert.Equal(t, maxEdits+1, changed[len(changed)-1])
+}
+
+func Test_DiffCoverage(t *testing.T) {
+	profiles, err := ParseProfiles(`mode: set
+example.com/app/a.go:3.10,6.2 3 1
+example.com/app/a.go:8.10,11.2 2 0
+example.com/app/b.go:3.10,4.2 1 0
+`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		changed map[string][]int
+		want    DiffResult
+		percent float64
+	}{
+		{name: "No Changes",
+			want:    DiffResult{},
+			percent: 100,
+		},
+		{name: "Changed Lines Without Statements",
+			changed: map[string][]int{"a.go": {1, 7}},
+			want:    DiffResult{},
+			percent: 100,
+		},
+		{name: "Partially Covered",
+			changed: map[string][]int{"a.go": {2, 4, 5, 8, 9, 11}, "b.go": {3}},
+			want: DiffResult{
+				Lines:     6,
+				Covered:   2,
+				Uncovered: []string{"a.go:8-9", "a.go:11", "b.go:3"},
+			},
+			percent: 100 * 2 / 6.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffCoverage(profiles, "example.com/app", tt.changed)
+			assert.Equal(t, tt.want, got)
+			assert.InDelta(t, tt.percent, got.Percent(), 0.001)
+		})
+	}
+}
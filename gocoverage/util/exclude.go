@@ -0,0 +1,88 @@
+package util
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Excludes matches files to exclude from coverage.
+type Excludes struct {
+	regexps []*regexp.Regexp
+	globs   []string
+}
+
+// NewExcludes compiles regular expressions matched against file import paths, and validates glob
+// patterns matched against file import paths and paths relative to the module root, see MatchPath.
+func NewExcludes(regexps, globs []string) (*Excludes, error) {
+	e := &Excludes{globs: globs}
+	for _, expr := range regexps {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude %q: %w", expr, err)
+		}
+		e.regexps = append(e.regexps, re)
+	}
+	for _, glob := range globs {
+		if !validGlob(glob) {
+			return nil, fmt.Errorf("invalid exclude glob %q", glob)
+		}
+	}
+	return e, nil
+}
+
+func validGlob(glob string) bool {
+	for elem := range strings.SplitSeq(glob, "/") {
+		if _, err := path.Match(elem, ""); err != nil {
+			return false
+		}
+	}
+	return glob != ""
+}
+
+// Empty reports whether no files are excluded.
+func (e *Excludes) Empty() bool {
+	return len(e.regexps) == 0 && len(e.globs) == 0
+}
+
+// Match reports whether a file, given its import path, is excluded.
+func (e *Excludes) Match(fileName, modulePath string) bool {
+	for _, re := range e.regexps {
+		if re.MatchString(fileName) {
+			return true
+		}
+	}
+	rel := TrimModule(fileName, modulePath)
+	for _, glob := range e.globs {
+		if MatchPath(glob, fileName) || MatchPath(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterProfile removes the blocks of excluded files from a text format coverage profile.
+func FilterProfile(data string, exclude func(fileName string) bool) string {
+	var sb strings.Builder
+	for line := range strings.Lines(data) {
+		if match := blockRegex.FindStringSubmatch(strings.TrimSpace(line)); match != nil && exclude(match[1]) {
+			continue
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// IsGenerated reports whether Go source has a "// Code generated ... DO NOT EDIT." comment
+// before the package clause, see https://go.dev/s/generatedcode.
+func IsGenerated(src string) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+	return ast.IsGenerated(file)
+}
@@ -0,0 +1,93 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Excludes(t *testing.T) {
+	excludes, err := NewExcludes([]string{`\.gen\.go$`}, []string{"internal/mocks/**", "example.com/app/cmd/*/*.go"})
+	require.NoError(t, err)
+	assert.False(t, excludes.Empty())
+
+	tests := []struct {
+		fileName string
+		want     bool
+	}{
+		{fileName: "example.com/app/internal/lib.gen.go", want: true},
+		{fileName: "example.com/app/internal/lib.go", want: false},
+		{fileName: "example.com/app/internal/mocks/foo/mock.go", want: true},
+		{fileName: "example.com/app/cmd/app/main.go", want: true},
+		{fileName: "example.com/app/cmd/main.go", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			assert.Equal(t, tt.want, excludes.Match(tt.fileName, "example.com/app"))
+		})
+	}
+
+	empty, err := NewExcludes(nil, nil)
+	require.NoError(t, err)
+	assert.True(t, empty.Empty())
+
+	_, err = NewExcludes([]string{"("}, nil)
+	assert.Error(t, err)
+	_, err = NewExcludes(nil, []string{"internal/["})
+	assert.Error(t, err)
+}
+
+func Test_FilterProfile(t *testing.T) {
+	data := `mode: set
+example.com/app/a.go:3.24,5.2 1 1
+example.com/app/a.gen.go:3.24,5.2 1 1
+mode: set
+example.com/app/b.go:3.24,5.2 1 0
+`
+	expected := `mode: set
+example.com/app/a.go:3.24,5.2 1 1
+mode: set
+example.com/app/b.go:3.24,5.2 1 0
+`
+	got := FilterProfile(data, func(fileName string) bool { return fileName == "example.com/app/a.gen.go" })
+	assert.Equal(t, expected, got)
+}
+
+func Test_IsGenerated(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{name: "Generated",
+			src:  "// Code generated by mockgen. DO NOT EDIT.\n\npackage foo\n",
+			want: true,
+		},
+		{name: "After License",
+			src:  "// Copyright 2026\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage foo\n",
+			want: true,
+		},
+		{name: "After Package Clause",
+			src:  "package foo\n\n// Code generated by mockgen. DO NOT EDIT.\n",
+			want: false,
+		},
+		{name: "Not Generated",
+			src:  "// Package foo does things.\npackage foo\n",
+			want: false,
+		},
+		{name: "Missing Period",
+			src:  "// Code generated by hand. DO NOT EDIT\npackage foo\n",
+			want: false,
+		},
+		{name: "Invalid Go",
+			src:  "// Code generated by mockgen. DO NOT EDIT.\n",
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsGenerated(tt.src))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+)
+
+// FuncCoverage is the statement coverage of a function.
+type FuncCoverage struct {
+	// Name is qualified with the package import path and receiver,
+	// e.g. example.com/foo.Bar or example.com/foo.(*Baz).Bar.
+	Name string
+	// ID identifies the function in its package, the file and the function name with its receiver,
+	// e.g. example.com/foo/foo.go:(*Baz).Bar. Repeated names, such as init, are numbered from the second,
+	// e.g. example.com/foo/foo.go:init#2.
+	ID string
+	// File is the import path of the file declaring the function.
+	File string
+	// Line of the function declaration.
+	Line int
+	// Statements is the number of statements in the function.
+	Statements int
+	// Covered is the number of statements executed at least once.
+	Covered int
+}
+
+// Percent of statements covered, 0 if the function has no statements.
+func (fc FuncCoverage) Percent() float64 {
+	return PackageCoverage{Statements: fc.Statements, Covered: fc.Covered}.Percent()
+}
+
+// Functions returns the statement coverage of the functions declared in the source of a profile's file,
+// in order of declaration, like `go tool cover -func`.
+func Functions(p *Profile, src string) ([]FuncCoverage, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path.Base(p.FileName), src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", p.FileName, err)
+	}
+
+	var funcs []FuncCoverage
+	seen := make(map[string]int)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		start, end := fset.Position(fn.Pos()), fset.Position(fn.End())
+		name := funcName(fn)
+		seen[name]++
+		id := p.FileName + ":" + name
+		if n := seen[name]; n > 1 {
+			id += fmt.Sprintf("#%d", n)
+		}
+		fc := FuncCoverage{
+			Name: p.Package() + "." + name,
+			ID:   id,
+			File: p.FileName,
+			Line: start.Line,
+		}
+		for _, b := range p.Blocks {
+			if before(b.StartLine, b.StartCol, start.Line, start.Column) || before(end.Line, end.Column, b.EndLine, b.EndCol) {
+				continue
+			}
+			fc.Statements += b.NumStmt
+			if b.Count > 0 {
+				fc.Covered += b.NumStmt
+			}
+		}
+		funcs = append(funcs, fc)
+	}
+	return funcs, nil
+}
+
+// before reports whether position a is before position b.
+func before(aLine, aCol, bLine, bCol int) bool {
+	return aLine < bLine || (aLine == bLine && aCol < bCol)
+}
+
+// funcName returns the name of a function, with its receiver type for methods, e.g. (*Baz).Bar.
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	return "(" + recvType(fn.Recv.List[0].Type) + ")." + fn.Name.Name
+}
+
+func recvType(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + recvType(t.X)
+	case *ast.IndexExpr:
+		return recvType(t.X)
+	case *ast.IndexListExpr:
+		return recvType(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const funcsSource = `package foo
+
+type Baz[T any] struct{}
+
+func Bar(x int) int {
+	if x > 0 {
+		return x
+	}
+	return -x
+}
+
+func (b *Baz[T]) Qux() {}
+
+func (b Baz[T]) Quux() int {
+	return 1
+}
+
+func init() {}
+
+func init() {}
+`
+
+func Test_Functions(t *testing.T) {
+	profiles, err := ParseProfiles(`mode: set
+example.com/foo/foo.go:5.21,6.12 1 1
+example.com/foo/foo.go:6.12,8.3 1 1
+example.com/foo/foo.go:9.2,9.11 1 0
+example.com/foo/foo.go:12.24,12.25 0 0
+example.com/foo/foo.go:14.28,16.2 1 0
+`)
+	require.NoError(t, err)
+
+	got, err := Functions(profiles[0], funcsSource)
+	require.NoError(t, err)
+	assert.Equal(t, []FuncCoverage{
+		{Name: "example.com/foo.Bar", ID: "example.com/foo/foo.go:Bar", File: "example.com/foo/foo.go", Line: 5, Statements: 3, Covered: 2},
+		{Name: "example.com/foo.(*Baz).Qux", ID: "example.com/foo/foo.go:(*Baz).Qux", File: "example.com/foo/foo.go", Line: 12, Statements: 0, Covered: 0},
+		{Name: "example.com/foo.(Baz).Quux", ID: "example.com/foo/foo.go:(Baz).Quux", File: "example.com/foo/foo.go", Line: 14, Statements: 1, Covered: 0},
+		{Name: "example.com/foo.init", ID: "example.com/foo/foo.go:init", File: "example.com/foo/foo.go", Line: 18},
+		{Name: "example.com/foo.init", ID: "example.com/foo/foo.go:init#2", File: "example.com/foo/foo.go", Line: 20},
+	}, got)
+	assert.InDelta(t, 100*2/3.0, got[0].Percent(), 0.001)
+
+	_, err = Functions(profiles[0], "not go")
+	assert.Error(t, err)
+}
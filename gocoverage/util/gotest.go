@@ -0,0 +1,285 @@
+package util
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// TestEvent is a line of `go test -json` output, see `go doc test2json`.
+type TestEvent struct {
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	ImportPath  string
+	FailedBuild string
+}
+
+// Test results.
+const (
+	TestPass = "pass"
+	TestFail = "fail"
+	TestSkip = "skip"
+)
+
+// TestCase is the result of a test, or of a subtest named "TestParent/sub".
+type TestCase struct {
+	Package string
+	Name    string
+	// Result is one of TestPass, TestFail or TestSkip.
+	Result string
+	// Elapsed time in seconds.
+	Elapsed float64
+	Output  string
+}
+
+// TestPackage is the result of testing a package.
+type TestPackage struct {
+	Name string
+	// Result is one of TestPass, TestFail or TestSkip, the latter for packages without tests.
+	Result string
+	// Elapsed time in seconds.
+	Elapsed float64
+	// Output of the package that is not part of a test, e.g. build errors.
+	Output string
+	// BuildFailed is true if the package or its tests did not compile.
+	BuildFailed bool
+	Tests       []TestCase
+}
+
+// ParseTestEvents parses `go test -json` output into the results of each package, sorted by name.
+// Packages that are tested more than once, e.g. from several runs concatenated together, are reported once
+// per run.  Lines that are not JSON, e.g. from a test writing directly to stdout, are ignored.
+func ParseTestEvents(data string) ([]*TestPackage, error) {
+	var pkgs []*TestPackage
+	current := map[string]*TestPackage{}
+	tests := map[string]*TestCase{}
+	buildOutput := map[string]*strings.Builder{}
+
+	pkg := func(name string) *TestPackage {
+		p, ok := current[name]
+		if !ok {
+			p = &TestPackage{Name: name}
+			current[name] = p
+			pkgs = append(pkgs, p)
+		}
+		return p
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var e TestEvent
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid go test -json event %q: %w", line, err)
+		}
+
+		if e.Action == "build-output" {
+			if _, ok := buildOutput[e.ImportPath]; !ok {
+				buildOutput[e.ImportPath] = &strings.Builder{}
+			}
+			buildOutput[e.ImportPath].WriteString(e.Output)
+			continue
+		}
+		if e.Package == "" {
+			continue
+		}
+
+		p := pkg(e.Package)
+		if e.Test == "" {
+			switch e.Action {
+			case "output":
+				p.Output += e.Output
+			case TestPass, TestFail, TestSkip:
+				p.Result = e.Action
+				p.Elapsed = e.Elapsed
+				if e.FailedBuild != "" {
+					p.BuildFailed = true
+					if out, ok := buildOutput[e.FailedBuild]; ok {
+						p.Output = out.String() + p.Output
+					}
+				}
+				// the next events of this package are from another run
+				delete(current, e.Package)
+			}
+			continue
+		}
+
+		key := e.Package + " " + e.Test
+		tc, ok := tests[key]
+		if !ok {
+			tc = &TestCase{Package: e.Package, Name: e.Test}
+			tests[key] = tc
+		}
+		switch e.Action {
+		case "output":
+			tc.Output += e.Output
+		case TestPass, TestFail, TestSkip:
+			tc.Result = e.Action
+			tc.Elapsed = e.Elapsed
+			p.Tests = append(p.Tests, *tc)
+			delete(tests, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading go test -json output: %w", err)
+	}
+
+	// tests without a result, e.g. when the test binary panicked or timed out
+	for _, tc := range tests {
+		tc.Result = TestFail
+		p := pkg(tc.Package)
+		p.Tests = append(p.Tests, *tc)
+	}
+
+	slices.SortStableFunc(pkgs, func(a, b *TestPackage) int { return cmp.Compare(a.Name, b.Name) })
+	return pkgs, nil
+}
+
+// TestSummary counts the test results.
+type TestSummary struct {
+	Passed  int
+	Failed  int
+	Skipped int
+	// Failures are the failed tests, and the packages that failed outside of a test, e.g. to build.
+	Failures []TestCase
+	// Slowest tests, slowest first.
+	Slowest []TestCase
+}
+
+// SummarizeTests counts the results of the tests and keeps the slowest n tests.
+// Packages failing outside of a test count as a failed test.
+func SummarizeTests(pkgs []*TestPackage, n int) TestSummary {
+	var s TestSummary
+	var all []TestCase
+	for _, p := range pkgs {
+		for _, tc := range p.Tests {
+			switch tc.Result {
+			case TestPass:
+				s.Passed++
+			case TestFail:
+				s.Failed++
+				s.Failures = append(s.Failures, tc)
+			case TestSkip:
+				s.Skipped++
+			}
+			all = append(all, tc)
+		}
+		if tc, ok := packageFailure(p); ok {
+			s.Failed++
+			s.Failures = append(s.Failures, tc)
+		}
+	}
+
+	slices.SortStableFunc(all, func(a, b TestCase) int { return cmp.Compare(b.Elapsed, a.Elapsed) })
+	s.Slowest = all[:min(max(n, 0), len(all))]
+	return s
+}
+
+// packageFailure is a test case for a package that failed outside of a test, e.g. to build.
+func packageFailure(p *TestPackage) (TestCase, bool) {
+	if p.Result != TestFail || slices.ContainsFunc(p.Tests, func(tc TestCase) bool { return tc.Result == TestFail }) {
+		return TestCase{}, false
+	}
+	name := "[setup failed]"
+	if p.BuildFailed {
+		name = "[build failed]"
+	}
+	return TestCase{Package: p.Name, Name: name, Result: TestFail, Elapsed: p.Elapsed, Output: p.Output}, true
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure"`
+	Error     *junitMessage `xml:"error"`
+	Skipped   *junitMessage `xml:"skipped"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit converts the test results to JUnit XML, with a test suite per package.
+// Packages failing outside of a test, e.g. to build, are reported as an error.
+func JUnit(pkgs []*TestPackage) (string, error) {
+	report := junitTestSuites{}
+	var total float64
+	for _, p := range pkgs {
+		suite := junitTestSuite{Name: p.Name, Time: seconds(p.Elapsed)}
+		for _, tc := range p.Tests {
+			c := junitTestCase{Name: tc.Name, Classname: tc.Package, Time: seconds(tc.Elapsed)}
+			switch tc.Result {
+			case TestFail:
+				c.Failure = &junitMessage{Message: "Failed", Text: tc.Output}
+				suite.Failures++
+			case TestSkip:
+				c.Skipped = &junitMessage{Message: "Skipped", Text: tc.Output}
+				suite.Skipped++
+			default:
+				c.SystemOut = tc.Output
+			}
+			suite.TestCases = append(suite.TestCases, c)
+		}
+		if tc, ok := packageFailure(p); ok {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name:      tc.Name,
+				Classname: tc.Package,
+				Time:      seconds(tc.Elapsed),
+				Error:     &junitMessage{Message: "Failed", Text: tc.Output},
+			})
+			suite.Errors++
+		} else {
+			suite.SystemOut = p.Output
+		}
+		suite.Tests = len(suite.TestCases)
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		total += p.Elapsed
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = seconds(total)
+
+	return marshalXML(report, "")
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEvents = `{"Action":"start","Package":"example.com/app/a"}
+{"Action":"run","Package":"example.com/app/a","Test":"TestPass"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Action":"pass","Package":"example.com/app/a","Test":"TestPass","Elapsed":0.5}
+{"Action":"run","Package":"example.com/app/a","Test":"TestFail"}
+{"Action":"output","Package":"example.com/app/a","Test":"TestFail","Output":"    a_test.go:9: wrong\n"}
+{"Action":"fail","Package":"example.com/app/a","Test":"TestFail","Elapsed":1.25}
+{"Action":"run","Package":"example.com/app/a","Test":"TestSkip"}
+{"Action":"skip","Package":"example.com/app/a","Test":"TestSkip","Elapsed":0}
+{"Action":"output","Package":"example.com/app/a","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/app/a","Elapsed":1.8}
+{"ImportPath":"example.com/app/b [example.com/app/b.test]","Action":"build-output","Output":"b_test.go:3:1: syntax error\n"}
+{"ImportPath":"example.com/app/b [example.com/app/b.test]","Action":"build-fail"}
+{"Action":"start","Package":"example.com/app/b"}
+{"Action":"output","Package":"example.com/app/b","Output":"FAIL\texample.com/app/b [build failed]\n"}
+{"Action":"fail","Package":"example.com/app/b","Elapsed":0,"FailedBuild":"example.com/app/b [example.com/app/b.test]"}
+not json
+{"Action":"start","Package":"example.com/app"}
+{"Action":"output","Package":"example.com/app","Output":"?   \texample.com/app\t[no test files]\n"}
+{"Action":"skip","Package":"example.com/app","Elapsed":0}
+`
+
+func Test_ParseTestEvents(t *testing.T) {
+	pkgs, err := ParseTestEvents(testEvents)
+	require.NoError(t, err)
+
+	require.Len(t, pkgs, 3)
+	assert.Equal(t, &TestPackage{Name: "example.com/app", Result: TestSkip, Output: "?   \texample.com/app\t[no test files]\n"}, pkgs[0])
+	assert.Equal(t, &TestPackage{
+		Name:    "example.com/app/a",
+		Result:  TestFail,
+		Elapsed: 1.8,
+		Output:  "FAIL\n",
+		Tests: []TestCase{
+			{Package: "example.com/app/a", Name: "TestPass", Result: TestPass, Elapsed: 0.5, Output: "=== RUN   TestPass\n"},
+			{Package: "example.com/app/a", Name: "TestFail", Result: TestFail, Elapsed: 1.25, Output: "    a_test.go:9: wrong\n"},
+			{Package: "example.com/app/a", Name: "TestSkip", Result: TestSkip},
+		},
+	}, pkgs[1])
+	assert.Equal(t, &TestPackage{
+		Name:        "example.com/app/b",
+		Result:      TestFail,
+		Output:      "b_test.go:3:1: syntax error\nFAIL\texample.com/app/b [build failed]\n",
+		BuildFailed: true,
+	}, pkgs[2])
+
+	_, err = ParseTestEvents(`{"Action":`)
+	assert.Error(t, err)
+}
+
+func Test_ParseTestEventsUnfinished(t *testing.T) {
+	pkgs, err := ParseTestEvents(`{"Action":"run","Package":"example.com/app","Test":"TestHang"}
+{"Action":"output","Package":"example.com/app","Test":"TestHang","Output":"panic: test timed out\n"}
+`)
+	require.NoError(t, err)
+	require.Len(t, pkgs, 1)
+	assert.Equal(t, []TestCase{
+		{Package: "example.com/app", Name: "TestHang", Result: TestFail, Output: "panic: test timed out\n"},
+	}, pkgs[0].Tests)
+}
+
+func Test_SummarizeTests(t *testing.T) {
+	pkgs, err := ParseTestEvents(testEvents)
+	require.NoError(t, err)
+
+	s := SummarizeTests(pkgs, 2)
+	assert.Equal(t, 1, s.Passed)
+	assert.Equal(t, 2, s.Failed)
+	assert.Equal(t, 1, s.Skipped)
+	require.Len(t, s.Failures, 2)
+	assert.Equal(t, "TestFail", s.Failures[0].Name)
+	assert.Equal(t, "[build failed]", s.Failures[1].Name)
+	require.Len(t, s.Slowest, 2)
+	assert.Equal(t, "TestFail", s.Slowest[0].Name)
+	assert.Equal(t, "TestPass", s.Slowest[1].Name)
+
+	assert.Empty(t, SummarizeTests(pkgs, 0).Slowest)
+}
+
+func Test_JUnit(t *testing.T) {
+	pkgs, err := ParseTestEvents(testEvents)
+	require.NoError(t, err)
+
+	got, err := JUnit(pkgs)
+	require.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="4" failures="1" errors="1" skipped="1" time="1.800">
+	<testsuite name="example.com/app" tests="0" failures="0" errors="0" skipped="0" time="0.000">
+		<system-out>?   &#x9;example.com/app&#x9;[no test files]&#xA;</system-out>
+	</testsuite>
+	<testsuite name="example.com/app/a" tests="3" failures="1" errors="0" skipped="1" time="1.800">
+		<testcase name="TestPass" classname="example.com/app/a" time="0.500">
+			<system-out>=== RUN   TestPass&#xA;</system-out>
+		</testcase>
+		<testcase name="TestFail" classname="example.com/app/a" time="1.250">
+			<failure message="Failed">    a_test.go:9: wrong&#xA;</failure>
+		</testcase>
+		<testcase name="TestSkip" classname="example.com/app/a" time="0.000">
+			<skipped message="Skipped"></skipped>
+		</testcase>
+		<system-out>FAIL&#xA;</system-out>
+	</testsuite>
+	<testsuite name="example.com/app/b" tests="1" failures="0" errors="1" skipped="0" time="0.000">
+		<testcase name="[build failed]" classname="example.com/app/b" time="0.000">
+			<error message="Failed">b_test.go:3:1: syntax error&#xA;FAIL&#x9;example.com/app/b [build failed]&#xA;</error>
+		</testcase>
+	</testsuite>
+</testsuites>
+`, got)
+}
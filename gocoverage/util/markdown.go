@@ -0,0 +1,184 @@
+package util
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MarkdownReport is the coverage to render as a markdown comment.
+type MarkdownReport struct {
+	Profiles  []*Profile
+	Functions []FuncCoverage
+	// Comparison with a baseline, optional.
+	Comparison *Comparison
+	// LeastCovered is the number of least covered functions to list.
+	LeastCovered int
+}
+
+// Markdown renders the total coverage, a table of package coverage and the least covered functions,
+// with the change from the baseline if compared. Table rows are dropped to keep the comment within
+// limit bytes, e.g. 65536 for GitHub comments, and then whole sections, so no table or <details> is cut.
+func Markdown(r MarkdownReport, limit int) string {
+	pkgs := Packages(r.Profiles)
+
+	var funcs []FuncCoverage
+	for _, fc := range r.Functions {
+		if fc.Statements > 0 && fc.Covered < fc.Statements {
+			funcs = append(funcs, fc)
+		}
+	}
+	slices.SortStableFunc(funcs, func(a, b FuncCoverage) int {
+		return cmp.Or(
+			cmp.Compare(a.Percent(), b.Percent()),
+			cmp.Compare(b.Statements-b.Covered, a.Statements-a.Covered),
+			cmp.Compare(a.Name, b.Name),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	funcs = funcs[:min(len(funcs), max(r.LeastCovered, 0))]
+
+	// drop rows from the longest table until the comment fits
+	nPkgs, nFuncs := len(pkgs), len(funcs)
+	for {
+		sections := renderMarkdown(r, pkgs, funcs, nPkgs, nFuncs)
+		if md := strings.Join(sections, ""); len(md) <= limit {
+			return md
+		}
+		if nPkgs == 0 && nFuncs == 0 {
+			return truncateSections(sections, limit)
+		}
+		if nPkgs >= nFuncs {
+			nPkgs -= max(1, nPkgs/10)
+		} else {
+			nFuncs -= max(1, nFuncs/10)
+		}
+	}
+}
+
+// renderMarkdown renders the sections of the report: the total, the package table, the least covered functions
+// and the warning about lost coverage, leaving out empty sections.
+func renderMarkdown(r MarkdownReport, pkgs []PackageCoverage, funcs []FuncCoverage, nPkgs, nFuncs int) []string {
+	var total PackageCoverage
+	for _, pc := range pkgs {
+		total.Statements += pc.Statements
+		total.Covered += pc.Covered
+	}
+
+	var pkgChanges, funcChanges map[string]Delta
+	if r.Comparison != nil {
+		pkgChanges = deltasByName(r.Comparison.Packages)
+		funcChanges = deltasByName(r.Comparison.Functions)
+	}
+
+	var sections []string
+	var sb strings.Builder
+	section := func() {
+		if sb.Len() != 0 {
+			sections = append(sections, sb.String())
+			sb.Reset()
+		}
+	}
+
+	fmt.Fprintf(&sb, "## Code coverage: %.2f%%", total.Percent())
+	if r.Comparison != nil {
+		fmt.Fprintf(&sb, " (%s)", formatChange(r.Comparison.Total))
+	}
+	fmt.Fprintf(&sb, "\n\n%d of %d statements covered.\n", total.Covered, total.Statements)
+	section()
+
+	if len(pkgs) != 0 {
+		sb.WriteString("\n| Package | Statements | Coverage |")
+		if r.Comparison != nil {
+			sb.WriteString(" Change |")
+		}
+		sb.WriteString("\n| --- | ---: | ---: |")
+		if r.Comparison != nil {
+			sb.WriteString(" ---: |")
+		}
+		sb.WriteString("\n")
+		for _, pc := range pkgs[:nPkgs] {
+			fmt.Fprintf(&sb, "| `%s` | %d | %.2f%% |", pc.Package, pc.Statements, pc.Percent())
+			if r.Comparison != nil {
+				fmt.Fprintf(&sb, " %s |", formatChange(pkgChanges[pc.Package]))
+			}
+			sb.WriteString("\n")
+		}
+		if hidden := len(pkgs) - nPkgs; hidden > 0 {
+			fmt.Fprintf(&sb, "\n_%d more packages not shown._\n", hidden)
+		}
+	}
+	section()
+
+	if len(funcs) != 0 {
+		sb.WriteString("\n<details>\n<summary>Least covered functions</summary>\n\n| Function | Statements | Coverage |")
+		if r.Comparison != nil {
+			sb.WriteString(" Change |")
+		}
+		sb.WriteString("\n| --- | ---: | ---: |")
+		if r.Comparison != nil {
+			sb.WriteString(" ---: |")
+		}
+		sb.WriteString("\n")
+		for _, fc := range funcs[:nFuncs] {
+			fmt.Fprintf(&sb, "| `%s` | %d | %.2f%% |", fc.Name, fc.Statements, fc.Percent())
+			if r.Comparison != nil {
+				fmt.Fprintf(&sb, " %s |", formatChange(funcChanges[fc.ID]))
+			}
+			sb.WriteString("\n")
+		}
+		if hidden := len(funcs) - nFuncs; hidden > 0 {
+			fmt.Fprintf(&sb, "\n_%d more functions not shown._\n", hidden)
+		}
+		sb.WriteString("\n</details>\n")
+	}
+	section()
+
+	if r.Comparison != nil {
+		if lost := Regressions(r.Comparison.Functions, 0); len(lost) != 0 {
+			fmt.Fprintf(&sb, "\n:warning: %d function(s) lost coverage.\n", len(lost))
+		}
+	}
+	section()
+
+	return sections
+}
+
+func deltasByName(deltas []Delta) map[string]Delta {
+	m := make(map[string]Delta, len(deltas))
+	for _, d := range deltas {
+		m[d.Name] = d
+	}
+	return m
+}
+
+// formatChange formats a delta in percentage points, e.g. "+1.50", or "new" if added.
+func formatChange(d Delta) string {
+	if d.Added {
+		return "new"
+	}
+	return fmt.Sprintf("%+.2f", d.Change())
+}
+
+// truncateSections drops sections from the end until the rest fits in limit bytes. If the first section,
+// the total, does not fit by itself, it is cut, as it has no markup that could be left open.
+func truncateSections(sections []string, limit int) string {
+	for n := len(sections); n > 1; n-- {
+		if md := strings.Join(sections[:n], ""); len(md) <= limit {
+			return md
+		}
+	}
+	return truncate(sections[0], limit)
+}
+
+// truncate cuts s to at most limit bytes, without splitting a UTF-8 character.
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	for limit > 0 && s[limit]&0xC0 == 0x80 {
+		limit--
+	}
+	return s[:limit]
+}
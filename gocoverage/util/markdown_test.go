@@ -0,0 +1,122 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func markdownReport(t *testing.T) MarkdownReport {
+	t.Helper()
+	profiles, err := ParseProfiles(`mode: set
+example.com/app/main.go:5.13,7.2 2 0
+example.com/app/internal/a.go:3.24,5.2 3 1
+example.com/app/internal/a.go:7.24,9.2 1 0
+`)
+	require.NoError(t, err)
+
+	return MarkdownReport{
+		Profiles: profiles,
+		Functions: []FuncCoverage{
+			{Name: "example.com/app.main", ID: "example.com/app/main.go:main", Statements: 2, Covered: 0},
+			{Name: "example.com/app/internal.A", ID: "example.com/app/internal/a.go:A", Statements: 3, Covered: 3},
+			{Name: "example.com/app/internal.B", ID: "example.com/app/internal/a.go:B", Statements: 1, Covered: 0},
+		},
+		LeastCovered: 10,
+	}
+}
+
+func Test_Markdown(t *testing.T) {
+	expected := "## Code coverage: 50.00%\n" +
+		"\n" +
+		"3 of 6 statements covered.\n" +
+		"\n" +
+		"| Package | Statements | Coverage |\n" +
+		"| --- | ---: | ---: |\n" +
+		"| `example.com/app` | 2 | 0.00% |\n" +
+		"| `example.com/app/internal` | 4 | 75.00% |\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary>Least covered functions</summary>\n" +
+		"\n" +
+		"| Function | Statements | Coverage |\n" +
+		"| --- | ---: | ---: |\n" +
+		"| `example.com/app.main` | 2 | 0.00% |\n" +
+		"| `example.com/app/internal.B` | 1 | 0.00% |\n" +
+		"\n" +
+		"</details>\n"
+
+	assert.Equal(t, expected, Markdown(markdownReport(t), 65536))
+}
+
+func Test_MarkdownComparison(t *testing.T) {
+	r := markdownReport(t)
+	r.LeastCovered = 1
+	r.Comparison = &Comparison{
+		Total: Delta{Before: 60, After: 50},
+		Packages: []Delta{
+			{Name: "example.com/app", After: 0, Added: true},
+			{Name: "example.com/app/internal", Before: 100, After: 75},
+		},
+		Functions: []Delta{
+			{Name: "example.com/app/main.go:main", Added: true},
+			{Name: "example.com/app/internal/a.go:B", Before: 100, After: 0},
+		},
+	}
+
+	expected := "## Code coverage: 50.00% (-10.00)\n" +
+		"\n" +
+		"3 of 6 statements covered.\n" +
+		"\n" +
+		"| Package | Statements | Coverage | Change |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| `example.com/app` | 2 | 0.00% | new |\n" +
+		"| `example.com/app/internal` | 4 | 75.00% | -25.00 |\n" +
+		"\n" +
+		"<details>\n" +
+		"<summary>Least covered functions</summary>\n" +
+		"\n" +
+		"| Function | Statements | Coverage | Change |\n" +
+		"| --- | ---: | ---: | ---: |\n" +
+		"| `example.com/app.main` | 2 | 0.00% | new |\n" +
+		"\n" +
+		"</details>\n" +
+		"\n" +
+		":warning: 1 function(s) lost coverage.\n"
+
+	assert.Equal(t, expected, Markdown(r, 65536))
+}
+
+func Test_MarkdownLimit(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("mode: set\n")
+	for i := range 1000 {
+		fmt.Fprintf(&sb, "example.com/app/pkg%d/a.go:3.24,5.2 1 1\n", i)
+	}
+	profiles, err := ParseProfiles(sb.String())
+	require.NoError(t, err)
+
+	md := Markdown(MarkdownReport{Profiles: profiles}, 4096)
+	assert.LessOrEqual(t, len(md), 4096)
+	assert.Contains(t, md, "## Code coverage: 100.00%")
+	assert.Regexp(t, `_\d+ more packages not shown._`, md)
+
+	assert.Equal(t, "## Code", Markdown(MarkdownReport{Profiles: profiles}, 7))
+}
+
+func Test_MarkdownLimitSections(t *testing.T) {
+	r := markdownReport(t)
+	total := "## Code coverage: 50.00%\n\n3 of 6 statements covered.\n"
+
+	for limit := len(total); limit < len(Markdown(r, 65536)); limit++ {
+		md := Markdown(r, limit)
+		assert.LessOrEqual(t, len(md), limit)
+		assert.True(t, strings.HasPrefix(md, total), md)
+		assert.Equal(t, strings.Count(md, "<details>"), strings.Count(md, "</details>"), md)
+		assert.True(t, strings.HasSuffix(md, "\n"), md)
+	}
+	assert.Equal(t, total, Markdown(r, len(total)))
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MergeProfiles merges the profiles of several coverage runs, which must use the same cover mode.
+// Counts of the same block are merged with MergeCount.
+func MergeProfiles(runs ...[]*Profile) ([]*Profile, error) {
+	files := map[string]*Profile{}
+	mode := ""
+	for _, profiles := range runs {
+		for _, p := range profiles {
+			if mode == "" {
+				mode = p.Mode
+			}
+			if p.Mode != mode {
+				return nil, fmt.Errorf("cannot merge %s coverage of %s with %s coverage", p.Mode, p.FileName, mode)
+			}
+
+			merged, ok := files[p.FileName]
+			if !ok {
+				merged = &Profile{FileName: p.FileName, Mode: p.Mode}
+				files[p.FileName] = merged
+			}
+			merged.Blocks = append(merged.Blocks, p.Blocks...)
+		}
+	}
+
+	result := make([]*Profile, 0, len(files))
+	for _, p := range files {
+		p.Blocks = mergeBlocks(p.Blocks, p.Mode)
+		result = append(result, p)
+	}
+	slices.SortFunc(result, func(a, b *Profile) int { return cmp.Compare(a.FileName, b.FileName) })
+	return result, nil
+}
+
+// FormatProfiles writes profiles in the text format, with the mode used if there are no profiles.
+func FormatProfiles(profiles []*Profile, mode string) string {
+	if len(profiles) != 0 {
+		mode = profiles[0].Mode
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "mode: %s\n", mode)
+	for _, p := range profiles {
+		for _, b := range p.Blocks {
+			fmt.Fprintf(&sb, "%s:%d.%d,%d.%d %d %d\n", p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count)
+		}
+	}
+	return sb.String()
+}
+
+// MatchPackagePattern reports whether a package import path matches a go package pattern,
+// where "..." matches any string, e.g. "example.com/foo/..." matches example.com/foo and all packages below it.
+func MatchPackagePattern(pattern, pkg string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	// "foo/..." also matches "foo"
+	if trimmed, ok := strings.CutSuffix(expr, `/\.\.\.`); ok {
+		expr = trimmed + `(/.*)?`
+	}
+	expr = strings.ReplaceAll(expr, `\.\.\.`, `.*`)
+	return regexp.MustCompile(`^` + expr + `$`).MatchString(pkg)
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MergeProfiles(t *testing.T) {
+	parse := func(data string) []*Profile {
+		profiles, err := ParseProfiles(data)
+		require.NoError(t, err)
+		return profiles
+	}
+
+	tests := []struct {
+		name    string
+		runs    [][]*Profile
+		want    string
+		wantErr bool
+	}{
+		{name: "Set Takes Max",
+			runs: [][]*Profile{
+				parse("mode: set\nexample.com/a/a.go:3.10,5.2 1 0\nexample.com/a/a.go:7.10,9.2 1 1\n"),
+				parse("mode: set\nexample.com/a/a.go:3.10,5.2 1 1\nexample.com/a/a.go:7.10,9.2 1 1\n"),
+			},
+			want: "mode: set\nexample.com/a/a.go:3.10,5.2 1 1\nexample.com/a/a.go:7.10,9.2 1 1\n",
+		},
+		{name: "Count Sums",
+			runs: [][]*Profile{
+				parse("mode: count\nexample.com/a/a.go:3.10,5.2 1 2\n"),
+				parse("mode: count\nexample.com/a/a.go:3.10,5.2 1 3\nexample.com/b/b.go:3.10,5.2 1 0\n"),
+			},
+			want: "mode: count\nexample.com/a/a.go:3.10,5.2 1 5\nexample.com/b/b.go:3.10,5.2 1 0\n",
+		},
+		{name: "Atomic Sums",
+			runs: [][]*Profile{
+				parse("mode: atomic\nexample.com/a/a.go:3.10,5.2 1 2\n"),
+				parse("mode: atomic\nexample.com/a/a.go:3.10,5.2 1 3\n"),
+			},
+			want: "mode: atomic\nexample.com/a/a.go:3.10,5.2 1 5\n",
+		},
+		{name: "Mixed Modes",
+			runs: [][]*Profile{
+				parse("mode: set\nexample.com/a/a.go:3.10,5.2 1 1\n"),
+				parse("mode: count\nexample.com/a/a.go:3.10,5.2 1 3\n"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeProfiles(tt.runs...)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, FormatProfiles(got, "set"))
+		})
+	}
+}
+
+func Test_FormatProfilesEmpty(t *testing.T) {
+	assert.Equal(t, "mode: count\n", FormatProfiles(nil, "count"))
+}
+
+func Test_MatchPackagePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkg     string
+		want    bool
+	}{
+		{pattern: "example.com/app", pkg: "example.com/app", want: true},
+		{pattern: "example.com/app", pkg: "example.com/app/internal", want: false},
+		{pattern: "example.com/app/...", pkg: "example.com/app", want: true},
+		{pattern: "example.com/app/...", pkg: "example.com/app/internal/gen", want: true},
+		{pattern: "example.com/app/...", pkg: "example.com/application", want: false},
+		{pattern: "example.com/.../gen", pkg: "example.com/app/internal/gen", want: true},
+		{pattern: "example.com/a.p", pkg: "example.com/abp", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.pkg, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPackagePattern(tt.pattern, tt.pkg))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PackageCoverage is the statement coverage of a package.
+type PackageCoverage struct {
+	// Package is the import path of the package.
+	Package string
+	// Statements is the number of statements in the package.
+	Statements int
+	// Covered is the number of statements executed at least once.
+	Covered int
+}
+
+// Percent of statements covered, 0 if the package has no statements.
+func (pc PackageCoverage) Percent() float64 {
+	if pc.Statements == 0 {
+		return 0
+	}
+	return 100 * float64(pc.Covered) / float64(pc.Statements)
+}
+
+// Packages sums the statement coverage of the files in each package, sorted by package.
+func Packages(profiles []*Profile) []PackageCoverage {
+	pkgs := map[string]*PackageCoverage{}
+	for _, p := range profiles {
+		pc, ok := pkgs[p.Package()]
+		if !ok {
+			pc = &PackageCoverage{Package: p.Package()}
+			pkgs[p.Package()] = pc
+		}
+		total, covered := p.Statements()
+		pc.Statements += total
+		pc.Covered += covered
+	}
+
+	result := make([]PackageCoverage, 0, len(pkgs))
+	for _, pc := range pkgs {
+		result = append(result, *pc)
+	}
+	slices.SortFunc(result, func(a, b PackageCoverage) int { return cmp.Compare(a.Package, b.Package) })
+
+	return result
+}
+
+// Minimum is the minimum coverage percentage of the packages matching a glob pattern.
+type Minimum struct {
+	// Pattern matches package import paths, see MatchPath.
+	Pattern string
+	// Percent is the minimum percentage of statements covered.
+	Percent float64
+}
+
+// ParseMinimums parses minimums in the form "<pattern>=<percent>", e.g. "example.com/foo/**=80".
+func ParseMinimums(specs []string) ([]Minimum, error) {
+	minimums := make([]Minimum, 0, len(specs))
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid package minimum %q: expected <pattern>=<percent>", spec)
+		}
+		pattern := spec[:i]
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return nil, fmt.Errorf("invalid package minimum %q: %w", spec, err)
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(spec[i+1:], "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid package minimum %q: percent is not a number: %w", spec, err)
+		}
+		minimums = append(minimums, Minimum{Pattern: pattern, Percent: percent})
+	}
+	return minimums, nil
+}
+
+// MatchPath reports whether a slash separated path, e.g. a package import path, matches a glob pattern.
+// Patterns are matched per path element with path.Match, and "**" matches any number of elements,
+//
+//	e.g. "example.com/foo/**" matches example.com/foo and all packages below it.
+func MatchPath(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchElems(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], elems[0])
+	return err == nil && ok && matchElems(pattern[1:], elems[1:])
+}
+
+// CheckPackages checks that every package meets the minimums of the patterns it matches.
+// A pattern that matches no package is an error, as it most likely has a typo.
+func CheckPackages(pkgs []PackageCoverage, minimums []Minimum) error {
+	var errs []error
+	for _, m := range minimums {
+		matched := false
+		for _, pc := range pkgs {
+			if !MatchPath(m.Pattern, pc.Package) {
+				continue
+			}
+			matched = true
+			if pc.Percent() < m.Percent {
+				errs = append(errs, fmt.Errorf("code coverage of %.2f%% in %s is insufficient (need at least %.2f%% for %s)",
+					pc.Percent(), pc.Package, m.Percent, m.Pattern))
+			}
+		}
+		if !matched {
+			errs = append(errs, fmt.Errorf("package pattern %s does not match any package", m.Pattern))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Shard distributes packages round-robin into at most n non-empty shards, keeping their order within a shard.
+func Shard(pkgs []string, n int) [][]string {
+	n = max(min(n, len(pkgs)), 1)
+	shards := make([][]string, n)
+	for i, pkg := range pkgs {
+		shards[i%n] = append(shards[i%n], pkg)
+	}
+	return shards
+}
@@ -0,0 +1,115 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const packagesProfile = `mode: set
+example.com/app/main.go:5.13,7.2 2 0
+example.com/app/internal/a.go:3.24,5.2 1 1
+example.com/app/internal/b.go:3.24,5.2 3 1
+example.com/app/internal/b.go:8.24,10.2 1 0
+example.com/app/internal/gen/c.go:3.24,5.2 1 1
+`
+
+func Test_Packages(t *testing.T) {
+	profiles, err := ParseProfiles(packagesProfile)
+	require.NoError(t, err)
+
+	assert.Equal(t, []PackageCoverage{
+		{Package: "example.com/app", Statements: 2, Covered: 0},
+		{Package: "example.com/app/internal", Statements: 5, Covered: 4},
+		{Package: "example.com/app/internal/gen", Statements: 1, Covered: 1},
+	}, Packages(profiles))
+}
+
+func Test_PackageCoveragePercent(t *testing.T) {
+	assert.InDelta(t, 80.0, PackageCoverage{Statements: 5, Covered: 4}.Percent(), 0.001)
+	assert.Zero(t, PackageCoverage{}.Percent())
+}
+
+func Test_MatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		pkg     string
+		want    bool
+	}{
+		{pattern: "example.com/app", pkg: "example.com/app", want: true},
+		{pattern: "example.com/app", pkg: "example.com/app/internal", want: false},
+		{pattern: "example.com/app/*", pkg: "example.com/app/internal", want: true},
+		{pattern: "example.com/app/*", pkg: "example.com/app/internal/gen", want: false},
+		{pattern: "example.com/app/**", pkg: "example.com/app", want: true},
+		{pattern: "example.com/app/**", pkg: "example.com/app/internal/gen", want: true},
+		{pattern: "**/gen", pkg: "example.com/app/internal/gen", want: true},
+		{pattern: "**/internal/**", pkg: "example.com/app/internal/gen", want: true},
+		{pattern: "example.com/*/internal", pkg: "example.com/app/internal", want: true},
+		{pattern: "example.com/ap?", pkg: "example.com/app", want: true},
+		{pattern: "example.org/**", pkg: "example.com/app", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.pkg, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchPath(tt.pattern, tt.pkg))
+		})
+	}
+}
+
+func Test_ParseMinimums(t *testing.T) {
+	got, err := ParseMinimums([]string{"example.com/app/**=80", "**/gen=50.5%"})
+	require.NoError(t, err)
+	assert.Equal(t, []Minimum{
+		{Pattern: "example.com/app/**", Percent: 80},
+		{Pattern: "**/gen", Percent: 50.5},
+	}, got)
+
+	for _, spec := range []string{"example.com/app", "=80", "example.com/app=high", "example.com/[=80"} {
+		_, err := ParseMinimums([]string{spec})
+		assert.Error(t, err, spec)
+	}
+}
+
+func Test_CheckPackages(t *testing.T) {
+	profiles, err := ParseProfiles(packagesProfile)
+	require.NoError(t, err)
+	pkgs := Packages(profiles)
+
+	tests := []struct {
+		name     string
+		minimums []Minimum
+		wantErr  []string
+	}{
+		{name: "Met",
+			minimums: []Minimum{{Pattern: "example.com/app/internal/**", Percent: 80}},
+		},
+		{name: "Insufficient",
+			minimums: []Minimum{{Pattern: "example.com/app/**", Percent: 50}},
+			wantErr:  []string{"code coverage of 0.00% in example.com/app is insufficient (need at least 50.00% for example.com/app/**)"},
+		},
+		{name: "No Match",
+			minimums: []Minimum{{Pattern: "example.com/other", Percent: 50}},
+			wantErr:  []string{"package pattern example.com/other does not match any package"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPackages(pkgs, tt.minimums)
+			if len(tt.wantErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, msg := range tt.wantErr {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func Test_Shard(t *testing.T) {
+	pkgs := []string{"a", "b", "c", "d", "e"}
+	assert.Equal(t, [][]string{{"a", "c", "e"}, {"b", "d"}}, Shard(pkgs, 2))
+	assert.Equal(t, [][]string{{"a"}, {"b"}, {"c"}, {"d"}, {"e"}}, Shard(pkgs, 8))
+	assert.Equal(t, [][]string{pkgs}, Shard(pkgs, 0))
+	assert.Equal(t, [][]string{nil}, Shard(nil, 3))
+}
@@ -0,0 +1,155 @@
+package util
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Block is a block of statements in a coverage profile.
+type Block struct {
+	StartLine int
+	StartCol  int
+	EndLine   int
+	EndCol    int
+	// NumStmt is the number of statements in the block.
+	NumStmt int
+	// Count is the number of times the block was executed, 0 or 1 in set mode.
+	Count int
+}
+
+// Profile is the coverage of a single file.
+type Profile struct {
+	// FileName is the import path of the file, e.g. example.com/foo/bar.go.
+	FileName string
+	// Mode is the cover mode, one of set, count or atomic.
+	Mode string
+	// Blocks are sorted by position.
+	Blocks []Block
+}
+
+// blockRegex matches a block in a text format coverage profile, e.g. "example.com/foo/bar.go:3.24,5.2 1 1".
+var blockRegex = regexp.MustCompile(`^(.+):([0-9]+)\.([0-9]+),([0-9]+)\.([0-9]+) ([0-9]+) ([0-9]+)$`)
+
+// ParseProfiles parses a text format coverage profile, e.g. from `go test -coverprofile`.
+// Blocks found more than once are merged, see MergeCount.
+// Profiles are sorted by file name.
+func ParseProfiles(data string) ([]*Profile, error) {
+	files := map[string]*Profile{}
+	mode := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "mode: "):
+			// profiles concatenated from several packages repeat the mode line
+			m := strings.TrimPrefix(line, "mode: ")
+			if mode != "" && m != mode {
+				return nil, fmt.Errorf("line %d: cover mode %s does not match %s", n, m, mode)
+			}
+			mode = m
+			continue
+		case mode == "":
+			return nil, fmt.Errorf("line %d: expected a mode line first, got %q", n, line)
+		}
+
+		match := blockRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: invalid coverage block %q", n, line)
+		}
+		nums := make([]int, 0, 6)
+		for _, s := range match[2:] {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			nums = append(nums, v)
+		}
+
+		p, ok := files[match[1]]
+		if !ok {
+			p = &Profile{FileName: match[1], Mode: mode}
+			files[match[1]] = p
+		}
+		p.Blocks = append(p.Blocks, Block{
+			StartLine: nums[0],
+			StartCol:  nums[1],
+			EndLine:   nums[2],
+			EndCol:    nums[3],
+			NumStmt:   nums[4],
+			Count:     nums[5],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading coverage profile: %w", err)
+	}
+
+	profiles := make([]*Profile, 0, len(files))
+	for _, p := range files {
+		p.Blocks = mergeBlocks(p.Blocks, p.Mode)
+		profiles = append(profiles, p)
+	}
+	slices.SortFunc(profiles, func(a, b *Profile) int { return cmp.Compare(a.FileName, b.FileName) })
+
+	return profiles, nil
+}
+
+// MergeCount merges the counts of the same block from two profiles.
+// In set mode a block is covered if either is covered, otherwise the counts are summed.
+func MergeCount(mode string, a, b int) int {
+	if mode == "set" {
+		return max(a, b)
+	}
+	return a + b
+}
+
+// mergeBlocks sorts blocks by position and merges blocks at the same position.
+func mergeBlocks(blocks []Block, mode string) []Block {
+	slices.SortStableFunc(blocks, func(a, b Block) int {
+		return cmp.Or(
+			cmp.Compare(a.StartLine, b.StartLine),
+			cmp.Compare(a.StartCol, b.StartCol),
+			cmp.Compare(a.EndLine, b.EndLine),
+			cmp.Compare(a.EndCol, b.EndCol),
+		)
+	})
+
+	merged := blocks[:0]
+	for _, b := range blocks {
+		if n := len(merged); n != 0 && samePosition(merged[n-1], b) {
+			merged[n-1].Count = MergeCount(mode, merged[n-1].Count, b.Count)
+			continue
+		}
+		merged = append(merged, b)
+	}
+	return merged
+}
+
+func samePosition(a, b Block) bool {
+	return a.StartLine == b.StartLine && a.StartCol == b.StartCol && a.EndLine == b.EndLine && a.EndCol == b.EndCol
+}
+
+// Package is the import path of the package containing the file.
+func (p *Profile) Package() string {
+	return path.Dir(p.FileName)
+}
+
+// Statements returns the number of statements, and the number of covered statements, in the file.
+func (p *Profile) Statements() (total, covered int) {
+	for _, b := range p.Blocks {
+		total += b.NumStmt
+		if b.Count > 0 {
+			covered += b.NumStmt
+		}
+	}
+	return total, covered
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseProfiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []*Profile
+		wantErr bool
+	}{
+		{name: "Sorted",
+			data: `mode: set
+example.com/b/b.go:3.24,5.2 1 0
+example.com/a/a.go:7.2,8.3 2 1
+example.com/a/a.go:3.24,5.2 1 1
+`,
+			want: []*Profile{
+				{FileName: "example.com/a/a.go", Mode: "set", Blocks: []Block{
+					{StartLine: 3, StartCol: 24, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+					{StartLine: 7, StartCol: 2, EndLine: 8, EndCol: 3, NumStmt: 2, Count: 1},
+				}},
+				{FileName: "example.com/b/b.go", Mode: "set", Blocks: []Block{
+					{StartLine: 3, StartCol: 24, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 0},
+				}},
+			},
+		},
+		{name: "Merge Set",
+			data: `mode: set
+example.com/a/a.go:3.24,5.2 1 1
+mode: set
+example.com/a/a.go:3.24,5.2 1 1
+`,
+			want: []*Profile{
+				{FileName: "example.com/a/a.go", Mode: "set", Blocks: []Block{
+					{StartLine: 3, StartCol: 24, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 1},
+				}},
+			},
+		},
+		{name: "Merge Count",
+			data: `mode: count
+example.com/a/a.go:3.24,5.2 1 3
+example.com/a/a.go:3.24,5.2 1 4
+`,
+			want: []*Profile{
+				{FileName: "example.com/a/a.go", Mode: "count", Blocks: []Block{
+					{StartLine: 3, StartCol: 24, EndLine: 5, EndCol: 2, NumStmt: 1, Count: 7},
+				}},
+			},
+		},
+		{name: "Empty",
+			data: "mode: atomic\n",
+			want: []*Profile{},
+		},
+		{name: "Missing Mode",
+			data:    "example.com/a/a.go:3.24,5.2 1 3\n",
+			wantErr: true,
+		},
+		{name: "Mismatched Mode",
+			data:    "mode: set\nmode: count\n",
+			wantErr: true,
+		},
+		{name: "Invalid Block",
+			data:    "mode: set\nexample.com/a/a.go:3.24 1 3\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProfiles(tt.data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ProfileStatements(t *testing.T) {
+	p := &Profile{FileName: "example.com/a/a.go", Mode: "count", Blocks: []Block{
+		{StartLine: 3, EndLine: 5, NumStmt: 2, Count: 4},
+		{StartLine: 6, EndLine: 7, NumStmt: 3, Count: 0},
+	}}
+
+	total, covered := p.Statements()
+	assert.Equal(t, 5, total)
+	assert.Equal(t, 2, covered)
+	assert.Equal(t, "example.com/a", p.Package())
+}
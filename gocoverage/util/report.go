@@ -0,0 +1,218 @@
+package util
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// LineCoverage is the execution count of a source line.
+type LineCoverage struct {
+	Line int
+	// Count is the highest count of the blocks on the line.
+	Count int
+}
+
+// Lines converts the blocks of a file to line coverage, sorted by line.
+// A line shared by several blocks, e.g. "} else {", takes the highest count.
+func (p *Profile) Lines() []LineCoverage {
+	counts := map[int]int{}
+	for _, b := range p.Blocks {
+		// blocks without statements, e.g. an empty function body, have nothing to cover
+		if b.NumStmt == 0 {
+			continue
+		}
+		for line := b.StartLine; line <= b.EndLine; line++ {
+			counts[line] = max(counts[line], b.Count)
+		}
+	}
+
+	lines := make([]LineCoverage, 0, len(counts))
+	for line, count := range counts {
+		lines = append(lines, LineCoverage{Line: line, Count: count})
+	}
+	slices.SortFunc(lines, func(a, b LineCoverage) int { return a.Line - b.Line })
+	return lines
+}
+
+// ModulePath returns the module path declared in a go.mod file.
+func ModulePath(gomod string) (string, error) {
+	for line := range strings.Lines(gomod) {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`+"`"), nil
+		}
+	}
+	return "", fmt.Errorf("go.mod does not declare a module path")
+}
+
+// TrimModule returns the path of a file relative to the module root,
+// or the file name unchanged if it is not in the module.
+func TrimModule(fileName, modulePath string) string {
+	if modulePath == "" {
+		return fileName
+	}
+	if rel, ok := strings.CutPrefix(fileName, modulePath+"/"); ok {
+		return rel
+	}
+	return fileName
+}
+
+// Lcov converts profiles to the LCOV tracefile format, with file paths relative to the module root.
+func Lcov(profiles []*Profile, modulePath string) string {
+	var sb strings.Builder
+	for _, p := range profiles {
+		lines := p.Lines()
+		hit := 0
+		fmt.Fprintf(&sb, "SF:%s\n", TrimModule(p.FileName, modulePath))
+		for _, l := range lines {
+			fmt.Fprintf(&sb, "DA:%d,%d\n", l.Line, l.Count)
+			if l.Count > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(&sb, "LF:%d\nLH:%d\nend_of_record\n", len(lines), hit)
+	}
+	return sb.String()
+}
+
+type sonarCoverage struct {
+	XMLName xml.Name    `xml:"coverage"`
+	Version int         `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path  string      `xml:"path,attr"`
+	Lines []sonarLine `xml:"lineToCover"`
+}
+
+type sonarLine struct {
+	LineNumber int  `xml:"lineNumber,attr"`
+	Covered    bool `xml:"covered,attr"`
+}
+
+// SonarGeneric converts profiles to the SonarQube generic test coverage format,
+// with file paths relative to the module root.
+// See: https://docs.sonarsource.com/sonarqube-server/latest/analyzing-source-code/test-coverage/generic-test-data/
+func SonarGeneric(profiles []*Profile, modulePath string) (string, error) {
+	report := sonarCoverage{Version: 1, Files: make([]sonarFile, 0, len(profiles))}
+	for _, p := range profiles {
+		file := sonarFile{Path: TrimModule(p.FileName, modulePath)}
+		for _, l := range p.Lines() {
+			file.Lines = append(file.Lines, sonarLine{LineNumber: l.Line, Covered: l.Count > 0})
+		}
+		report.Files = append(report.Files, file)
+	}
+	return marshalXML(report, "")
+}
+
+type coberturaCoverage struct {
+	XMLName         xml.Name           `xml:"coverage"`
+	LineRate        float64            `xml:"line-rate,attr"`
+	BranchRate      float64            `xml:"branch-rate,attr"`
+	LinesCovered    int                `xml:"lines-covered,attr"`
+	LinesValid      int                `xml:"lines-valid,attr"`
+	BranchesCovered int                `xml:"branches-covered,attr"`
+	BranchesValid   int                `xml:"branches-valid,attr"`
+	Complexity      float64            `xml:"complexity,attr"`
+	Version         string             `xml:"version,attr"`
+	Timestamp       int64              `xml:"timestamp,attr"`
+	Sources         []string           `xml:"sources>source"`
+	Packages        []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Complexity float64          `xml:"complexity,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Complexity float64         `xml:"complexity,attr"`
+	Methods    struct{}        `xml:"methods"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// Cobertura converts profiles to the Cobertura XML format, with a class per file and file paths
+// relative to the module root. Go coverage has no branch coverage, so branch rates are 0.
+func Cobertura(profiles []*Profile, modulePath string) (string, error) {
+	report := coberturaCoverage{Sources: []string{"."}}
+	pkgs := map[string]*coberturaPackage{}
+	var order []string
+	pkgLines := map[string][2]int{}
+
+	for _, p := range profiles {
+		name := p.Package()
+		pkg, ok := pkgs[name]
+		if !ok {
+			pkg = &coberturaPackage{Name: name}
+			pkgs[name] = pkg
+			order = append(order, name)
+		}
+
+		class := coberturaClass{
+			Name:     path.Base(p.FileName),
+			Filename: TrimModule(p.FileName, modulePath),
+		}
+		hit := 0
+		for _, l := range p.Lines() {
+			class.Lines = append(class.Lines, coberturaLine{Number: l.Line, Hits: l.Count})
+			if l.Count > 0 {
+				hit++
+			}
+		}
+		class.LineRate = rate(hit, len(class.Lines))
+		pkg.Classes = append(pkg.Classes, class)
+
+		counts := pkgLines[name]
+		pkgLines[name] = [2]int{counts[0] + hit, counts[1] + len(class.Lines)}
+		report.LinesCovered += hit
+		report.LinesValid += len(class.Lines)
+	}
+
+	slices.Sort(order)
+	for _, name := range order {
+		pkg := pkgs[name]
+		pkg.LineRate = rate(pkgLines[name][0], pkgLines[name][1])
+		report.Packages = append(report.Packages, *pkg)
+	}
+	report.LineRate = rate(report.LinesCovered, report.LinesValid)
+
+	return marshalXML(report, `<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`+"\n")
+}
+
+// rate is the ratio of covered to total, 0 if total is 0.
+func rate(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total)
+}
+
+func marshalXML(v any, doctype string) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(doctype)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("encoding the coverage report: %w", err)
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
@@ -0,0 +1,134 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reportProfile = `mode: count
+example.com/app/main.go:5.13,7.2 2 0
+example.com/app/internal/a.go:3.24,5.9 1 2
+example.com/app/internal/a.go:5.9,6.2 1 0
+example.com/app/internal/a.go:8.14,8.15 0 0
+`
+
+func reportProfiles(t *testing.T) []*Profile {
+	t.Helper()
+	profiles, err := ParseProfiles(reportProfile)
+	require.NoError(t, err)
+	return profiles
+}
+
+func Test_Lines(t *testing.T) {
+	profiles := reportProfiles(t)
+
+	assert.Equal(t, []LineCoverage{
+		{Line: 3, Count: 2},
+		{Line: 4, Count: 2},
+		{Line: 5, Count: 2},
+		{Line: 6, Count: 0},
+	}, profiles[0].Lines())
+}
+
+func Test_ModulePath(t *testing.T) {
+	got, err := ModulePath("// comment\nmodule example.com/app\n\ngo 1.25.0\n")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/app", got)
+
+	got, err = ModulePath("module \"example.com/quoted\"\n")
+	require.NoError(t, err)
+	assert.Equal(t, "example.com/quoted", got)
+
+	_, err = ModulePath("go 1.25.0\n")
+	assert.Error(t, err)
+}
+
+func Test_TrimModule(t *testing.T) {
+	assert.Equal(t, "internal/a.go", TrimModule("example.com/app/internal/a.go", "example.com/app"))
+	assert.Equal(t, "example.com/application/a.go", TrimModule("example.com/application/a.go", "example.com/app"))
+	assert.Equal(t, "example.com/app/a.go", TrimModule("example.com/app/a.go", ""))
+}
+
+func Test_Lcov(t *testing.T) {
+	expected := `SF:internal/a.go
+DA:3,2
+DA:4,2
+DA:5,2
+DA:6,0
+LF:4
+LH:3
+end_of_record
+SF:main.go
+DA:5,0
+DA:6,0
+DA:7,0
+LF:3
+LH:0
+end_of_record
+`
+	assert.Equal(t, expected, Lcov(reportProfiles(t), "example.com/app"))
+}
+
+func Test_SonarGeneric(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<coverage version="1">
+	<file path="internal/a.go">
+		<lineToCover lineNumber="3" covered="true"></lineToCover>
+		<lineToCover lineNumber="4" covered="true"></lineToCover>
+		<lineToCover lineNumber="5" covered="true"></lineToCover>
+		<lineToCover lineNumber="6" covered="false"></lineToCover>
+	</file>
+	<file path="main.go">
+		<lineToCover lineNumber="5" covered="false"></lineToCover>
+		<lineToCover lineNumber="6" covered="false"></lineToCover>
+		<lineToCover lineNumber="7" covered="false"></lineToCover>
+	</file>
+</coverage>
+`
+	got, err := SonarGeneric(reportProfiles(t), "example.com/app")
+	require.NoError(t, err)
+	assert.Equal(t, expected, got)
+}
+
+func Test_Cobertura(t *testing.T) {
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+<coverage line-rate="0.42857142857142855" branch-rate="0" lines-covered="3" lines-valid="7" branches-covered="0" branches-valid="0" complexity="0" version="" timestamp="0">
+	<sources>
+		<source>.</source>
+	</sources>
+	<packages>
+		<package name="example.com/app" line-rate="0" branch-rate="0" complexity="0">
+			<classes>
+				<class name="main.go" filename="main.go" line-rate="0" branch-rate="0" complexity="0">
+					<methods></methods>
+					<lines>
+						<line number="5" hits="0"></line>
+						<line number="6" hits="0"></line>
+						<line number="7" hits="0"></line>
+					</lines>
+				</class>
+			</classes>
+		</package>
+		<package name="example.com/app/internal" line-rate="0.75" branch-rate="0" complexity="0">
+			<classes>
+				<class name="a.go" filename="internal/a.go" line-rate="0.75" branch-rate="0" complexity="0">
+					<methods></methods>
+					<lines>
+						<line number="3" hits="2"></line>
+						<line number="4" hits="2"></line>
+						<line number="5" hits="2"></line>
+						<line number="6" hits="0"></line>
+					</lines>
+				</class>
+			</classes>
+		</package>
+	</packages>
+</coverage>
+`
+	got, err := Cobertura(reportProfiles(t), "example.com/app")
+	require.NoError(t, err)
+	assert.Equal(t, expected, got)
+}
@@ -0,0 +1,105 @@
+package util
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// UncoveredBlock is a range of lines with statements that were not executed.
+type UncoveredBlock struct {
+	Package string `json:"package"`
+	// File path relative to the module root.
+	File       string `json:"file"`
+	StartLine  int    `json:"startLine"`
+	EndLine    int    `json:"endLine"`
+	Statements int    `json:"statements"`
+	// Source lines from StartLine to EndLine.
+	Source string `json:"source"`
+}
+
+// Uncovered finds the uncovered blocks of a file, with consecutive uncovered blocks merged.
+// file is the path of the file relative to the module root and src its contents.
+func Uncovered(p *Profile, file, src string) []UncoveredBlock {
+	var blocks []UncoveredBlock
+	var current *UncoveredBlock
+	for _, b := range p.Blocks {
+		if b.Count > 0 || b.NumStmt == 0 {
+			current = nil
+			continue
+		}
+		if current != nil && b.StartLine <= current.EndLine+1 {
+			current.EndLine = max(current.EndLine, b.EndLine)
+			current.Statements += b.NumStmt
+			continue
+		}
+		blocks = append(blocks, UncoveredBlock{
+			Package:    p.Package(),
+			File:       file,
+			StartLine:  b.StartLine,
+			EndLine:    b.EndLine,
+			Statements: b.NumStmt,
+		})
+		current = &blocks[len(blocks)-1]
+	}
+
+	lines := splitLines(src)
+	for i := range blocks {
+		b := &blocks[i]
+		start := min(b.StartLine-1, len(lines))
+		end := min(b.EndLine, len(lines))
+		b.Source = strings.Join(lines[start:end], "\n")
+	}
+	return blocks
+}
+
+// Orders of uncovered blocks.
+const (
+	// SortByPackage orders by package, file and line.
+	SortByPackage = "package"
+	// SortBySize orders by the number of statements, most first.
+	SortBySize = "size"
+)
+
+// SortUncovered orders uncovered blocks by package or by size, see SortByPackage and SortBySize.
+func SortUncovered(blocks []UncoveredBlock, by string) {
+	byPosition := func(a, b UncoveredBlock) int {
+		return cmp.Or(
+			cmp.Compare(a.Package, b.Package),
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.StartLine, b.StartLine),
+		)
+	}
+	if by == SortBySize {
+		slices.SortStableFunc(blocks, func(a, b UncoveredBlock) int {
+			return cmp.Or(cmp.Compare(b.Statements, a.Statements), byPosition(a, b))
+		})
+		return
+	}
+	slices.SortStableFunc(blocks, byPosition)
+}
+
+// FormatUncovered writes uncovered blocks as text, each with a header and numbered source lines, e.g.
+//
+//	internal/foo.go:12-14 (1 statement)
+//	   12 | func Foo() int {
+//	   13 | 	return 1
+//	   14 | }
+func FormatUncovered(blocks []UncoveredBlock) string {
+	var sb strings.Builder
+	for i, b := range blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		statements := "statements"
+		if b.Statements == 1 {
+			statements = "statement"
+		}
+		fmt.Fprintf(&sb, "%s:%d-%d (%d %s)\n", b.File, b.StartLine, b.EndLine, b.Statements, statements)
+		for j, line := range strings.Split(b.Source, "\n") {
+			fmt.Fprintf(&sb, "%5d | %s\n", b.StartLine+j, line)
+		}
+	}
+	return sb.String()
+}
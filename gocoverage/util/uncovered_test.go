@@ -0,0 +1,95 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const uncoveredSource = `package foo
+
+func Bar(x int) int {
+	if x > 0 {
+		x++
+		return x
+	}
+	return -x
+}
+
+func Baz() int {
+	return 1
+}
+`
+
+func Test_Uncovered(t *testing.T) {
+	profiles, err := ParseProfiles(`mode: set
+example.com/foo/foo.go:3.21,4.12 1 1
+example.com/foo/foo.go:4.12,6.11 2 0
+example.com/foo/foo.go:8.2,8.11 1 1
+example.com/foo/foo.go:11.16,12.10 1 0
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []UncoveredBlock{
+		{Package: "example.com/foo", File: "foo.go", StartLine: 4, EndLine: 6, Statements: 2, Source: "\tif x > 0 {\n\t\tx++\n\t\treturn x"},
+		{Package: "example.com/foo", File: "foo.go", StartLine: 11, EndLine: 12, Statements: 1, Source: "func Baz() int {\n\treturn 1"},
+	}, Uncovered(profiles[0], "foo.go", uncoveredSource))
+}
+
+func Test_UncoveredMerged(t *testing.T) {
+	profiles, err := ParseProfiles(`mode: set
+example.com/foo/foo.go:3.21,4.12 1 0
+example.com/foo/foo.go:4.12,6.11 2 0
+example.com/foo/foo.go:8.2,8.11 1 0
+example.com/foo/foo.go:11.16,12.10 1 0
+`)
+	require.NoError(t, err)
+
+	got := Uncovered(profiles[0], "foo.go", uncoveredSource)
+	// the closing brace of the if statement separates the blocks
+	assert.Equal(t, []int{3, 8, 11}, startLines(got))
+	assert.Equal(t, 6, got[0].EndLine)
+	assert.Equal(t, 3, got[0].Statements)
+}
+
+func Test_SortUncovered(t *testing.T) {
+	blocks := []UncoveredBlock{
+		{Package: "example.com/b", File: "b/b.go", StartLine: 3, Statements: 1},
+		{Package: "example.com/a", File: "a/a.go", StartLine: 9, Statements: 1},
+		{Package: "example.com/a", File: "a/a.go", StartLine: 3, Statements: 5},
+	}
+
+	SortUncovered(blocks, SortByPackage)
+	assert.Equal(t, []int{3, 9, 3}, startLines(blocks))
+	assert.Equal(t, "example.com/b", blocks[2].Package)
+
+	SortUncovered(blocks, SortBySize)
+	assert.Equal(t, 5, blocks[0].Statements)
+	assert.Equal(t, "example.com/a", blocks[1].Package)
+	assert.Equal(t, "example.com/b", blocks[2].Package)
+}
+
+func startLines(blocks []UncoveredBlock) []int {
+	var lines []int
+	for _, b := range blocks {
+		lines = append(lines, b.StartLine)
+	}
+	return lines
+}
+
+func Test_FormatUncovered(t *testing.T) {
+	assert.Equal(t, `foo.go:4-6 (2 statements)
+    4 | 	if x > 0 {
+    5 | 		x++
+    6 | 		return x
+
+foo.go:11-12 (1 statement)
+   11 | func Baz() int {
+   12 | 	return 1
+`, FormatUncovered([]UncoveredBlock{
+		{File: "foo.go", StartLine: 4, EndLine: 6, Statements: 2, Source: "\tif x > 0 {\n\t\tx++\n\t\treturn x"},
+		{File: "foo.go", StartLine: 11, EndLine: 12, Statements: 1, Source: "func Baz() int {\n\treturn 1"},
+	}))
+	assert.Empty(t, FormatUncovered(nil))
+}
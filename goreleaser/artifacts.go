@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"dagger/goreleaser/internal/dagger"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Type of a goreleaser artifact.
+type ArtifactType string
+
+const (
+	// Executable built by goreleaser.
+	ArtifactTypeBinary ArtifactType = "BINARY"
+	// Archive of binaries and extra files, e.g. a tar.gz.
+	ArtifactTypeArchive ArtifactType = "ARCHIVE"
+	// Checksums file of the artifacts.
+	ArtifactTypeChecksum ArtifactType = "CHECKSUM"
+	// Container image built with docker, not a file in dist.
+	ArtifactTypeDockerImage ArtifactType = "DOCKER_IMAGE"
+	// Software bill of materials.
+	ArtifactTypeSbom ArtifactType = "SBOM"
+)
+
+// goreleaserTypes are the types of artifacts.json for each ArtifactType, e.g. binaries are
+// "Uploadable Binary" once a release prepared them for upload.
+var goreleaserTypes = map[ArtifactType][]string{
+	ArtifactTypeBinary:      {"Binary", "Uploadable Binary", "Universal Binary"},
+	ArtifactTypeArchive:     {"Archive"},
+	ArtifactTypeChecksum:    {"Checksum"},
+	ArtifactTypeDockerImage: {"Docker Image", "Published Docker Image"},
+	ArtifactTypeSbom:        {"SBOM"},
+}
+
+// An artifact produced by goreleaser, from dist/artifacts.json.
+type Artifact struct {
+	// Artifact name, e.g. hello-world-v0.2.0-linux-amd64.tar.gz.
+	Name string
+	// Path of the artifact relative to the project root, e.g. dist/hello-world_linux_amd64_v1/hello-world.
+	// For docker images this is the image reference.
+	Path string
+	// Artifact type as reported by goreleaser, e.g. "Binary", "Archive" or "Docker Image".
+	Type string
+	// Target operating system, if any.
+	Goos string
+	// Target architecture, if any.
+	Goarch string
+	// Target ARM version, if any.
+	Goarm string
+	// Target amd64 microarchitecture level, if any, e.g. v1.
+	Goamd64 string
+	// ID of the build, archive or other configuration that produced the artifact.
+	ID string
+
+	// path of the file relative to the dist directory, empty if the artifact is not a file
+	// +private
+	DistPath string
+
+	// +private
+	Dist *dagger.Directory
+}
+
+// File of the artifact.
+func (a *Artifact) File() (*dagger.File, error) {
+	if a.DistPath == "" {
+		return nil, fmt.Errorf("%s artifact %s is not a file in the dist directory", a.Type, a.Name)
+	}
+	return a.Dist.File(a.DistPath), nil
+}
+
+// Project metadata of a goreleaser run, from dist/metadata.json.
+type Metadata struct {
+	// Project name.
+	ProjectName string
+	// Current git tag.
+	Tag string
+	// Previous git tag.
+	PreviousTag string
+	// Version, the tag without a "v" prefix.
+	Version string
+	// Current git commit.
+	Commit string
+	// Date of the run, RFC 3339.
+	Date string
+}
+
+// Artifacts of a goreleaser dist directory.
+type Artifacts struct {
+	// Artifacts, after filtering.
+	Items []*Artifact
+
+	// Project metadata.
+	Metadata *Metadata
+}
+
+// Artifacts in a goreleaser dist directory, e.g. from a build or release.
+func (gr *Goreleaser) Artifacts(ctx context.Context,
+	// goreleaser dist directory, containing artifacts.json and metadata.json.
+	dist *dagger.Directory,
+
+	// Path of the dist directory relative to the project root, as configured in .goreleaser.yaml.
+	// +optional
+	// +default="dist"
+	distPath string,
+) (*Artifacts, error) {
+	return parseArtifacts(ctx, dist, distPath)
+}
+
+// Keep the artifacts matching all the given filters.
+func (a *Artifacts) Filter(
+	// artifact types to keep
+	// +optional
+	types []ArtifactType,
+
+	// target operating system, e.g. linux
+	// +optional
+	goos string,
+
+	// target architecture, e.g. amd64
+	// +optional
+	goarch string,
+
+	// IDs of the build, archive or other configuration that produced the artifacts
+	// +optional
+	ids []string,
+) *Artifacts {
+	var typeNames []string
+	for _, t := range types {
+		typeNames = append(typeNames, goreleaserTypes[t]...)
+	}
+
+	items := slices.DeleteFunc(slices.Clone(a.Items), func(item *Artifact) bool {
+		return (len(typeNames) != 0 && !slices.Contains(typeNames, item.Type)) ||
+			(goos != "" && item.Goos != goos) ||
+			(goarch != "" && item.Goarch != goarch) ||
+			(len(ids) != 0 && !slices.Contains(ids, item.ID))
+	})
+
+	return &Artifacts{
+		Items:    items,
+		Metadata: a.Metadata,
+	}
+}
+
+// Files of the artifacts, skipping artifacts that are not files, e.g. docker images.
+func (a *Artifacts) Files() []*dagger.File {
+	var files []*dagger.File
+	for _, item := range a.Items {
+		if item.DistPath != "" {
+			files = append(files, item.Dist.File(item.DistPath))
+		}
+	}
+	return files
+}
+
+// Names of the artifacts.
+func (a *Artifacts) Names() []string {
+	names := make([]string, 0, len(a.Items))
+	for _, item := range a.Items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+// goreleaserArtifact is an artifact as serialized in dist/artifacts.json.
+type goreleaserArtifact struct {
+	Name    string         `json:"name"`
+	Path    string         `json:"path"`
+	Goos    string         `json:"goos"`
+	Goarch  string         `json:"goarch"`
+	Goarm   string         `json:"goarm"`
+	Goamd64 string         `json:"goamd64"`
+	Type    string         `json:"type"`
+	Extra   map[string]any `json:"extra"`
+}
+
+// goreleaserMetadata is dist/metadata.json.
+type goreleaserMetadata struct {
+	ProjectName string `json:"project_name"`
+	Tag         string `json:"tag"`
+	PreviousTag string `json:"previous_tag"`
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	Date        string `json:"date"`
+}
+
+// parseArtifacts reads artifacts.json and metadata.json of a dist directory.
+func parseArtifacts(ctx context.Context, dist *dagger.Directory, distPath string) (*Artifacts, error) {
+	contents, err := dist.File("artifacts.json").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading artifacts.json: %w", err)
+	}
+	var raw []goreleaserArtifact
+	if err := json.Unmarshal([]byte(contents), &raw); err != nil {
+		return nil, fmt.Errorf("parsing artifacts.json: %w", err)
+	}
+
+	contents, err = dist.File("metadata.json").Contents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading metadata.json: %w", err)
+	}
+	var metadata goreleaserMetadata
+	if err := json.Unmarshal([]byte(contents), &metadata); err != nil {
+		return nil, fmt.Errorf("parsing metadata.json: %w", err)
+	}
+
+	distPrefix := path.Clean(distPath) + "/"
+	items := make([]*Artifact, 0, len(raw))
+	for _, r := range raw {
+		item := &Artifact{
+			Name:    r.Name,
+			Path:    r.Path,
+			Type:    r.Type,
+			Goos:    r.Goos,
+			Goarch:  r.Goarch,
+			Goarm:   r.Goarm,
+			Goamd64: r.Goamd64,
+			Dist:    dist,
+		}
+		if id, ok := r.Extra["ID"].(string); ok {
+			item.ID = id
+		}
+		if rel, ok := strings.CutPrefix(path.Clean(r.Path), distPrefix); ok {
+			item.DistPath = rel
+		}
+		items = append(items, item)
+	}
+
+	return &Artifacts{
+		Items: items,
+		Metadata: &Metadata{
+			ProjectName: metadata.ProjectName,
+			Tag:         metadata.Tag,
+			PreviousTag: metadata.PreviousTag,
+			Version:     metadata.Version,
+			Commit:      metadata.Commit,
+			Date:        metadata.Date,
+		},
+	}, nil
+}
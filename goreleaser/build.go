@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
 	"dagger/goreleaser/internal/dagger"
+	"fmt"
+	"path"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -36,37 +40,85 @@ func (b *Build) Platform(
 	// +optional
 	// +default="linux/amd64"
 	platform dagger.Platform,
-) *dagger.File {
-	p := platforms.MustParse(string(platform))
+) (*dagger.File, error) {
+	p, err := parsePlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+	return b.singleTarget(outFile, p), nil
+}
+
+// Build for several platforms concurrently, each as a separate, separately cached, `goreleaser build --single-target`.
+// Returns a directory with the executable of each platform at "[os]/[platform]/[version]/<outFile>",
+// e.g. "linux/amd64/hello-world".
+//
+// e.g. `goreleaser build --single-target` for each platform.
+func (b *Build) Platforms(
+	// output file name
+	outFile string,
+	// Target platforms in "[os]/[platform]/[version]" format (e.g., "darwin/arm64/v7", "windows/amd64", "linux/arm64").
+	targets []dagger.Platform,
+) (*dagger.Directory, error) {
+	dir := dag.Directory()
+	for _, target := range targets {
+		p, err := parsePlatform(target)
+		if err != nil {
+			return nil, err
+		}
+		dir = dir.WithFile(path.Join(p.OS, p.Architecture, p.Variant, outFile), b.singleTarget(outFile, p))
+	}
+	return dir, nil
+}
 
-	cmd := b.Command
+// singleTarget builds for one platform.
+func (b *Build) singleTarget(outFile string, p platforms.Platform) *dagger.File {
+	cmd := slices.Clone(b.Command)
 	cmd = append(cmd, "--single-target", "--output", outFile)
 
 	return b.Goreleaser.Container.
 		WithEnvVariable(envGOOS, p.OS).
 		WithEnvVariable(envGOARCH, p.Architecture).
 		With(func(c *dagger.Container) *dagger.Container {
-			if p.Variant != "" {
-				return c.WithEnvVariable(envGOARM, p.Variant)
+			switch {
+			case p.Variant == "":
+				return c
+			case p.Architecture == "amd64":
+				return c.WithEnvVariable(envGOAMD64, p.Variant)
+			default:
+				return c.WithEnvVariable(envGOARM, strings.TrimPrefix(p.Variant, "v"))
 			}
-
-			return c
 		}).
 		WithExec(cmd).
 		File(outFile)
 }
 
+// parsePlatform parses a platform, e.g. "linux/arm/v7".
+func parsePlatform(platform dagger.Platform) (platforms.Platform, error) {
+	p, err := platforms.Parse(string(platform))
+	if err != nil {
+		return p, fmt.Errorf("invalid platform %s: %w", platform, err)
+	}
+	return p, nil
+}
+
 // Build for all platforms, defined in .goreleaser.yaml. Returns the goreleaser 'dist' directory.
+// See Artifacts to select the executables.
 //
 // e.g. `goreleaser build`.
 func (b *Build) All() *dagger.Directory {
-	// TODO: Ideally, we would only return the executables. But that requires parsing the goreleaser
-	// config for the target platforms.
 	return b.Goreleaser.Container.
 		WithExec(b.Command).
 		Directory("dist")
 }
 
+// Build for all platforms, defined in .goreleaser.yaml. Returns the artifacts of the 'dist' directory,
+// e.g. `Filter(types: [BINARY]).Files` for the executables.
+//
+// e.g. `goreleaser build`.
+func (b *Build) Artifacts(ctx context.Context) (*Artifacts, error) {
+	return parseArtifacts(ctx, b.All(), "dist")
+}
+
 // Build an unversioned snapshot, skipping all validations.
 //
 // e.g. `goreleaser build --snapshot`.
@@ -124,15 +176,15 @@ func (b *Build) WithParallelism(
 	return b
 }
 
-// TODO: ensure this builds the flag correctly
 // Builds only the specified build ids, as defined in a goreleaser configuration file.
 //
-// e.g. `goreleaser build --id <id> <id> ...`
-// func (b *Build) WithIDs(
-// 	// Build IDs
-// 	ids []string,
-// ) *Build {
-// 	b.flags = append(b.flags, "--id")
-// 	b.flags = append(b.flags, ids...)
-// 	return b
-// }
+// e.g. `goreleaser build --id <id> --id <id> ...`
+func (b *Build) WithIDs(
+	// Build IDs
+	ids []string,
+) *Build {
+	for _, id := range ids {
+		b.Command = append(b.Command, "--id", id)
+	}
+	return b
+}
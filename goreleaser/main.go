@@ -22,6 +22,7 @@ const (
 	envGOOS       = "GOOS"
 	envGOARCH     = "GOARCH"
 	envGOARM      = "GOARM"
+	envGOAMD64    = "GOAMD64"
 )
 
 const (
@@ -18,6 +18,7 @@ import (
 	"context"
 	"dagger/tests/internal/dagger"
 	"fmt"
+	"slices"
 )
 
 type Tests struct {
@@ -67,6 +68,130 @@ func (t *Tests) BuildPlatform(ctx context.Context) error {
 		return fmt.Errorf("got nil build executable")
 	}
 
+	_, err := dag.Goreleaser(t.Source).
+		Build().
+		Platform("hello-world", dagger.GoreleaserBuildPlatformOpts{Platform: dagger.Platform("linux/amd64/v1/extra")}).
+		Sync(ctx)
+	if err == nil {
+		return fmt.Errorf("expected an error building an invalid platform")
+	}
+
+	return nil
+}
+
+// +check
+// Test concurrent builds for several platforms.
+func (t *Tests) BuildPlatforms(ctx context.Context) error {
+	dist := dag.Goreleaser(t.Source).
+		Build().
+		Platforms("hello-world", []dagger.Platform{"linux/amd64", "linux/arm/v7", "darwin/arm64"})
+
+	bins, err := dist.Glob(ctx, "**/hello-world")
+	if err != nil {
+		return err
+	}
+	slices.Sort(bins)
+
+	expected := []string{"darwin/arm64/hello-world", "linux/amd64/hello-world", "linux/arm/v7/hello-world"}
+	if !slices.Equal(bins, expected) {
+		return fmt.Errorf("executables did not match platforms, want %v, got %v", expected, bins)
+	}
+
+	return nil
+}
+
+// +check
+// Test build of specific build IDs.
+func (t *Tests) BuildIDs(ctx context.Context) error {
+	bins, err := dag.Goreleaser(t.gitRepoGo()).
+		Build().
+		WithIDs([]string{"hello-world"}).
+		Artifacts().
+		Filter(dagger.GoreleaserArtifactsFilterOpts{
+			Types: []dagger.GoreleaserArtifactType{dagger.GoreleaserArtifactTypeBinary},
+		}).
+		Files(ctx)
+	if err != nil {
+		return err
+	}
+	if len(bins) != 3*2 {
+		return fmt.Errorf("number of executables did not match build matrix, want %d, got %d", 3*2, len(bins))
+	}
+
+	_, err = dag.Goreleaser(t.gitRepoGo()).
+		Build().
+		WithIDs([]string{"missing"}).
+		All().
+		Sync(ctx)
+	if err == nil {
+		return fmt.Errorf("expected an error building an unknown build ID")
+	}
+
+	return nil
+}
+
+// +check
+// Test artifacts of a build for all platforms.
+func (t *Tests) BuildArtifacts(ctx context.Context) error {
+	artifacts := dag.Goreleaser(t.gitRepoGo()).
+		Build().
+		Artifacts()
+
+	binaries, err := artifacts.Filter(dagger.GoreleaserArtifactsFilterOpts{
+		Types: []dagger.GoreleaserArtifactType{dagger.GoreleaserArtifactTypeBinary},
+	}).Files(ctx)
+	if err != nil {
+		return err
+	}
+	if len(binaries) != 3*2 { // len(goos) * len(goarch)
+		return fmt.Errorf("number of executables did not match build matrix, want %d, got %d", 3*2, len(binaries))
+	}
+
+	names, err := artifacts.Filter(dagger.GoreleaserArtifactsFilterOpts{
+		Goos:   "windows",
+		Goarch: "arm64",
+	}).Names(ctx)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 || names[0] != "hello-world.exe" {
+		return fmt.Errorf("expected the windows/arm64 executable, got %v", names)
+	}
+
+	tag, err := artifacts.Metadata().Tag(ctx)
+	if err != nil {
+		return err
+	}
+	if tag != "v0.2.0" {
+		return fmt.Errorf("expected tag v0.2.0 in metadata, got %s", tag)
+	}
+
+	return nil
+}
+
+// +check
+// Test filtering the artifacts of a release by type.
+func (t *Tests) ArtifactTypes(ctx context.Context) error {
+	artifacts := dag.Goreleaser(t.gitRepoGo()).Artifacts(t.Source.Directory("dist"))
+
+	for artifactType, expected := range map[dagger.GoreleaserArtifactType][]string{
+		dagger.GoreleaserArtifactTypeBinary:      {"hello-world", "hello-world_linux_arm64", "hello-world"},
+		dagger.GoreleaserArtifactTypeArchive:     {"hello-world_0.2.0_linux_amd64.tar.gz"},
+		dagger.GoreleaserArtifactTypeChecksum:    {"checksums.txt"},
+		dagger.GoreleaserArtifactTypeDockerImage: {"ghcr.io/foo/hello-world:v0.2.0-amd64", "ghcr.io/foo/hello-world:v0.2.0-arm64"},
+		dagger.GoreleaserArtifactTypeSbom:        {"hello-world_0.2.0_linux_amd64.tar.gz.sbom.json"},
+	} {
+		names, err := artifacts.Filter(dagger.GoreleaserArtifactsFilterOpts{
+			Types: []dagger.GoreleaserArtifactType{artifactType},
+		}).Names(ctx)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(names, expected) {
+			return fmt.Errorf("%s artifacts did not match, want %v, got %v", artifactType, expected, names)
+		}
+	}
+
 	return nil
 }
 
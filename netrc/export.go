@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"dagger/netrc/internal/dagger"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// creates a git-credential-store file as a secret using provided credentials in WithLogin().
+//
+// Copy it to a writable file, git rewrites the file when storing or erasing credentials,
+// and point git at it with `git config --global credential.helper 'store --file <path>'`.
+func (m *Netrc) AsGitCredentials(ctx context.Context) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	for _, cred := range creds {
+		u := url.URL{
+			Scheme: "https",
+			User:   url.UserPassword(cred.Username, cred.Password),
+			Host:   cred.Machine,
+		}
+		sb.WriteString(u.String() + "\n")
+	}
+
+	return newSecret("GIT_CREDENTIALS", sb.String()), nil
+}
+
+// dockerConfig is the subset of a docker config.json used for registry authentication.
+type dockerConfig struct {
+	Auths map[string]dockerAuth `json:"auths"`
+}
+
+type dockerAuth struct {
+	Auth string `json:"auth"`
+}
+
+// creates a docker config.json as a secret using provided credentials in WithLogin().
+//
+// Mount it at $DOCKER_CONFIG/config.json, e.g. /root/.docker/config.json.
+// A docker config has a single login per machine, machines with several usernames are rejected.
+func (m *Netrc) AsDockerConfig(ctx context.Context) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := singleLogins(creds, "docker config"); err != nil {
+		return nil, err
+	}
+
+	cfg := dockerConfig{Auths: make(map[string]dockerAuth, len(creds))}
+	for _, cred := range creds {
+		cfg.Auths[cred.Machine] = dockerAuth{
+			Auth: basicAuth(cred.Username, cred.Password),
+		}
+	}
+
+	out, err := json.MarshalIndent(cfg, "", "\t")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode docker config: %w", err)
+	}
+
+	return newSecret("DOCKER_CONFIG", string(out)+"\n"), nil
+}
+
+// creates a .npmrc as a secret using provided credentials in WithLogin().
+//
+// Each machine is configured for basic auth, e.g. `//registry.example.com/:_auth=<base64>`.
+// An .npmrc has a single login per machine, machines with several usernames are rejected.
+func (m *Netrc) AsNpmrc(ctx context.Context) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := singleLogins(creds, ".npmrc"); err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	for _, cred := range creds {
+		sb.WriteString(fmt.Sprintf("//%s/:_auth=%s\n", cred.Machine, basicAuth(cred.Username, cred.Password)))
+	}
+
+	return newSecret("NPMRC", sb.String()), nil
+}
+
+// creates an env file of uv index credentials as a secret using provided credentials in WithLogin().
+//
+// The index name is derived from the machine name, e.g. machine pypi.example.com
+// sets UV_INDEX_PYPI_EXAMPLE_COM_USERNAME and UV_INDEX_PYPI_EXAMPLE_COM_PASSWORD.
+func (m *Netrc) AsUvIndexEnv(ctx context.Context) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	for _, cred := range creds {
+		name := envName(cred.Machine)
+		sb.WriteString(fmt.Sprintf("UV_INDEX_%s_USERNAME=%s\n", name, envValue(cred.Username)))
+		sb.WriteString(fmt.Sprintf("UV_INDEX_%s_PASSWORD=%s\n", name, envValue(cred.Password)))
+	}
+
+	return newSecret("UV_INDEX_ENV", sb.String()), nil
+}
+
+// creates an env file setting PIP_EXTRA_INDEX_URL as a secret using provided credentials in WithLogin().
+//
+// Every machine is added as an extra index with credentials embedded in the URL.
+func (m *Netrc) AsPipIndexEnv(ctx context.Context,
+	// path of the simple repository API on each machine
+	// +optional
+	// +default="/simple"
+	indexPath string,
+) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(creds))
+	for _, cred := range creds {
+		u := url.URL{
+			Scheme: "https",
+			User:   url.UserPassword(cred.Username, cred.Password),
+			Host:   cred.Machine,
+			Path:   indexPath,
+		}
+		urls = append(urls, u.String())
+	}
+
+	return newSecret("PIP_INDEX_ENV", fmt.Sprintf("PIP_EXTRA_INDEX_URL=%s\n", envValue(strings.Join(urls, " ")))), nil
+}
+
+// creates an env file setting GOPRIVATE and GONOSUMDB to the machines provided in WithLogin().
+//
+// Go reads the credentials themselves from the netrc created with AsSecret().
+func (m *Netrc) AsGoEnv(ctx context.Context) (*dagger.Secret, error) {
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var machines []string
+	for _, cred := range creds {
+		if !slices.Contains(machines, cred.Machine) {
+			machines = append(machines, cred.Machine)
+		}
+	}
+	patterns := strings.Join(machines, ",")
+
+	return newSecret("GO_ENV", fmt.Sprintf("GOPRIVATE=%s\nGONOSUMDB=%s\n", patterns, patterns)), nil
+}
+
+// singleLogins ensures every machine has a single login, for formats keyed by machine.
+func singleLogins(creds []credential, format string) error {
+	var machines []string
+	for _, cred := range creds {
+		if slices.Contains(machines, cred.Machine) {
+			return fmt.Errorf("machine %s has several logins, a %s supports one login per machine", cred.Machine, format)
+		}
+		machines = append(machines, cred.Machine)
+	}
+	return nil
+}
+
+// basicAuth encodes a username and password for HTTP basic authentication.
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+// envValue quotes a value of an env file, so whitespace, "#" and quotes survive dotenv parsing.
+// Values are single quoted, which dotenv parsers read literally, unless they contain a single quote
+// or a line break, then they are double quoted with escapes.
+func envValue(value string) string {
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+	return `"` + r.Replace(value) + `"`
+}
+
+var envNameInvalid = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// envName converts a machine name into an environment variable name segment,
+// e.g. pypi.example.com becomes PYPI_EXAMPLE_COM.
+func envName(machine string) string {
+	return envNameInvalid.ReplaceAllString(strings.ToUpper(machine), "_")
+}
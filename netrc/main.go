@@ -8,7 +8,10 @@ import (
 	"dagger/netrc/internal/dagger"
 	"encoding/hex"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 )
 
 type Netrc struct {
@@ -29,41 +32,145 @@ func New() *Netrc {
 	return &Netrc{}
 }
 
-const netrcTmpl = "machine %s\nlogin %s\npassword %s\n"
+const (
+	netrcTmpl = "machine %s\nlogin %s\npassword %s\n"
+	// netrcTokenTmpl is an entry without a username, e.g. for a token
+	netrcTokenTmpl = "machine %s\npassword %s\n"
+)
 
-// adds login credentials to netrc
+// adds login credentials to netrc. Adding the same machine and username again replaces its password.
+//
+// Logins are kept sorted by machine, logins for the same machine keep the order they were added in.
 func (m *Netrc) WithLogin(machine string, username string, password *dagger.Secret) *Netrc {
-	m.Logins = append(m.Logins, Login{
+	login := Login{
 		Machine:  machine,
 		Username: username,
 		Password: password,
+	}
+
+	i := slices.IndexFunc(m.Logins, func(l Login) bool {
+		return l.Machine == machine && l.Username == username
+	})
+	if i >= 0 {
+		m.Logins[i] = login
+		return m
+	}
+
+	m.Logins = append(m.Logins, login)
+	slices.SortStableFunc(m.Logins, func(a, b Login) int {
+		return strings.Compare(a.Machine, b.Machine)
 	})
 	return m
 }
 
+// removes login credentials for a machine from netrc
+func (m *Netrc) WithoutLogin(
+	// The remote machine name
+	machine string,
+	// only remove the login for this username, defaults to all logins for the machine
+	// +optional
+	username string,
+) *Netrc {
+	m.Logins = slices.DeleteFunc(m.Logins, func(l Login) bool {
+		return l.Machine == machine && (username == "" || l.Username == username)
+	})
+	return m
+}
+
+// A machine and username in a netrc, without its password.
+type MachineLogin struct {
+	// The remote machine name
+	Machine string
+	// username
+	Username string
+}
+
+// lists the machines and usernames added with WithLogin(), never their passwords
+func (m *Netrc) Machines() []MachineLogin {
+	machines := make([]MachineLogin, 0, len(m.Logins))
+	for _, login := range m.Logins {
+		machines = append(machines, MachineLogin{
+			Machine:  login.Machine,
+			Username: login.Username,
+		})
+	}
+	return machines
+}
+
 // creates a netrc as a secret using provided credentials in WithLogin()
 func (m *Netrc) AsSecret(ctx context.Context) (*dagger.Secret, error) {
-	if len(m.Logins) == 0 {
-		return nil, fmt.Errorf("no logins provided; call WithLogin first")
+	creds, err := m.credentials(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	var sb strings.Builder
 
+	for _, cred := range creds {
+		// Format and append this machine's entry to our netrc string
+		if cred.Username == "" {
+			sb.WriteString(fmt.Sprintf(netrcTokenTmpl, cred.Machine, cred.Password))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(netrcTmpl, cred.Machine, cred.Username, cred.Password))
+	}
+
+	return newSecret("NETRC_FILE", sb.String()), nil
+}
+
+// credential is a login with its password resolved to plaintext.
+type credential struct {
+	Machine  string
+	Username string
+	Password string
+}
+
+// credentials resolves the plaintext passwords of all logins, sorted by machine as kept by WithLogin().
+func (m *Netrc) credentials(ctx context.Context) ([]credential, error) {
+	if len(m.Logins) == 0 {
+		return nil, fmt.Errorf("no logins provided; call WithLogin first")
+	}
+
+	creds := make([]credential, 0, len(m.Logins))
 	for _, login := range m.Logins {
+		if err := validateLogin(login); err != nil {
+			return nil, err
+		}
+
 		password, err := login.Password.Plaintext(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read password secret for %s: %w", login.Machine, err)
 		}
+		creds = append(creds, credential{
+			Machine:  login.Machine,
+			Username: login.Username,
+			Password: password,
+		})
+	}
 
-		// Format and append this machine's entry to our netrc string
-		sb.WriteString(fmt.Sprintf(netrcTmpl, login.Machine, login.Username, password))
+	return creds, nil
+}
+
+// machineRegex matches a hostname, optionally with a port.
+var machineRegex = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?(:[0-9]+)?$`)
+
+// validateLogin ensures a login cannot corrupt the files it is written to.
+func validateLogin(login Login) error {
+	if !machineRegex.MatchString(login.Machine) {
+		return fmt.Errorf("invalid machine name %q: must be a hostname, optionally with a port", login.Machine)
+	}
+	if strings.ContainsFunc(login.Username, unicode.IsSpace) {
+		return fmt.Errorf("invalid username %q for %s: must not contain whitespace", login.Username, login.Machine)
 	}
+	return nil
+}
 
-	hash := sha256.Sum256([]byte(sb.String()))
+// newSecret creates a secret named with the given prefix and a short hash of its contents,
+// so identical contents share a secret while different contents never collide.
+func newSecret(prefix, contents string) *dagger.Secret {
+	hash := sha256.Sum256([]byte(contents))
 	hashStr := hex.EncodeToString(hash[:])[:8]
 
-	secretName := fmt.Sprintf("NETRC_FILE_%s", hashStr)
-	netrcSecret := dag.SetSecret(secretName, sb.String())
-
-	return netrcSecret, nil
+	secretName := fmt.Sprintf("%s_%s", prefix, hashStr)
+	return dag.SetSecret(secretName, contents)
 }
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"dagger/netrc/internal/dagger"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// mounts the netrc into a container for the given user and sets NETRC to its path.
+//
+// The netrc is mounted at ~/.netrc, owned by the user with 0600 permissions.
+func (m *Netrc) Mount(ctx context.Context,
+	// container to mount the netrc into
+	ctr *dagger.Container,
+	// user (name or uid) that will use the netrc, defaults to the container's user
+	// +optional
+	user string,
+	// configure git to use the logins for every machine, rewriting ssh URLs to https and adding a
+	// credential helper that reads the netrc. Mounting again does not duplicate the configuration.
+	// Requires git and awk in the container.
+	// +optional
+	git bool,
+) (*dagger.Container, error) {
+	netrc, err := m.AsSecret(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctrUser, err := ctr.User(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container user: %w", err)
+	}
+	if user == "" {
+		user = ctrUser
+	}
+
+	home, err := homeDir(ctx, ctr, user)
+	if err != nil {
+		return nil, err
+	}
+
+	netrcPath := path.Join(home, ".netrc")
+	ctr = ctr.
+		WithMountedSecret(netrcPath, netrc, dagger.ContainerWithMountedSecretOpts{
+			Owner: user,
+			Mode:  0o600,
+		}).
+		WithEnvVariable("NETRC", netrcPath)
+
+	if !git {
+		return ctr, nil
+	}
+
+	gitConfig := []string{"git", "config", "--file", path.Join(home, ".gitconfig")}
+
+	// run as the user so a newly created .gitconfig is owned by them
+	if user != ctrUser {
+		ctr = ctr.WithUser(user)
+	}
+
+	var machines []string
+	for _, login := range m.Logins {
+		if slices.Contains(machines, login.Machine) {
+			continue
+		}
+		machines = append(machines, login.Machine)
+
+		https := fmt.Sprintf("https://%s/", login.Machine)
+		insteadOf := fmt.Sprintf("url.%s.insteadOf", https)
+		for _, url := range []string{fmt.Sprintf("ssh://git@%s/", login.Machine), fmt.Sprintf("git@%s:", login.Machine)} {
+			// replace an existing rewrite of the same URL, or add it
+			ctr = ctr.WithExec(append(gitConfig, "--replace-all", "--fixed-value", insteadOf, url, url))
+		}
+		ctr = ctr.WithExec(append(gitConfig, "--replace-all", fmt.Sprintf("credential.%s.helper", https), gitCredentialHelper(netrcPath)))
+	}
+
+	if user != ctrUser {
+		ctr = ctr.WithUser(ctrUser)
+	}
+
+	return ctr, nil
+}
+
+// gitCredentialHelper is a read-only git credential helper answering get with the first login of the
+// requested host in the netrc. The netrc is a secret mount, so store and erase are ignored.
+func gitCredentialHelper(netrcPath string) string {
+	return `!f() { test "$1" = get || return 0; host=$(sed -n 's/^host=//p'); ` +
+		`awk -v host="$host" '$1 == "machine" { m = ($2 == host) } ` +
+		`m && $1 == "login" { print "username=" $2 } ` +
+		`m && $1 == "password" { sub(/^password /, ""); print "password=" $0; exit }' ` + netrcPath + `; }; f`
+}
+
+// homeDir resolves the home directory of a user from the container's /etc/passwd.
+func homeDir(ctx context.Context, ctr *dagger.Container, user string) (string, error) {
+	// drop the group, e.g. "1000:1000" or "builder:builder"
+	user, _, _ = strings.Cut(user, ":")
+	isRoot := user == "" || user == "root" || user == "0"
+
+	exists, err := ctr.Exists(ctx, "/etc/passwd")
+	if err != nil {
+		return "", fmt.Errorf("failed to check for /etc/passwd: %w", err)
+	}
+	if !exists {
+		if isRoot {
+			return "/root", nil
+		}
+		return "", fmt.Errorf("unable to resolve home directory of user %q: /etc/passwd not found", user)
+	}
+
+	passwd, err := ctr.File("/etc/passwd").Contents(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to read /etc/passwd: %w", err)
+	}
+
+	if isRoot {
+		user = "root"
+	}
+	home, ok := passwdHome(passwd, user)
+	switch {
+	case ok:
+		return home, nil
+	case isRoot:
+		return "/root", nil
+	default:
+		return "", fmt.Errorf("unable to resolve home directory of user %q: not found in /etc/passwd", user)
+	}
+}
+
+// passwdHome finds the home directory of a user, by name or uid, in the contents of an /etc/passwd file.
+func passwdHome(passwd, user string) (string, bool) {
+	for line := range strings.Lines(passwd) {
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) < 6 {
+			continue
+		}
+		if (fields[0] == user || fields[2] == user) && fields[5] != "" {
+			return fields[5], true
+		}
+	}
+	return "", false
+}